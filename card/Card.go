@@ -0,0 +1,193 @@
+package card
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =====================================================
+//
+// CARD VALIDATION
+//
+// Shared by xmlcore and jsoncore so a broken card number or
+// an expired card never reaches valitor.
+//
+// =====================================================
+
+var (
+	ErrNumberMissing     = errors.New("Card Number missing")
+	ErrInvalidNumber     = errors.New("Card Number can only contain digits")
+	ErrInvalidLength     = errors.New("Card Number has an invalid length")
+	ErrChecksum          = errors.New("Card Number failed the Luhn check")
+	ErrExpirationMissing = errors.New("Expiration Month and Year missing")
+	ErrExpirationMonth   = errors.New("Expiration Month missing")
+	ErrExpirationYear    = errors.New("Expiration Year missing")
+	ErrInvalidMonth      = errors.New("Expiration Month must be between 1 and 12")
+	ErrInvalidYear       = errors.New("Expiration Year is invalid")
+	ErrExpired           = errors.New("Card expired")
+)
+
+const (
+	minLength = 12
+	maxLength = 19
+)
+
+// Brand ...
+// The values match the cardType names used by ValitorPay.
+type Brand string
+
+const (
+	Unknown    Brand = ""
+	Visa       Brand = "Visa"
+	Mastercard Brand = "MasterCard"
+	Maestro    Brand = "Maestro"
+	Amex       Brand = "AmericanExpress"
+	Discover   Brand = "Discover"
+	JCB        Brand = "JCB"
+	DinersClub Brand = "DinersClub"
+	UnionPay   Brand = "UnionPay"
+)
+
+type brandRange struct {
+	Brand   Brand
+	From    int
+	To      int
+	Lengths []int
+}
+
+// brandRanges are checked in order, the first match wins.
+// From and To are compared against the start of the card number
+// with the same number of digits.
+var brandRanges = []brandRange{
+	{Amex, 34, 34, []int{15}},
+	{Amex, 37, 37, []int{15}},
+	{DinersClub, 300, 305, []int{14, 15, 16, 17, 18, 19}},
+	{DinersClub, 36, 36, []int{14, 15, 16, 17, 18, 19}},
+	{DinersClub, 38, 39, []int{14, 15, 16, 17, 18, 19}},
+	{JCB, 3528, 3589, []int{16, 17, 18, 19}},
+	{Visa, 4, 4, []int{13, 16, 19}},
+	{Maestro, 5018, 5018, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 5020, 5020, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 5038, 5038, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 5893, 5893, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Mastercard, 51, 55, []int{16}},
+	{Mastercard, 2221, 2720, []int{16}},
+	{Discover, 6011, 6011, []int{16, 17, 18, 19}},
+	{Discover, 622126, 622925, []int{16, 17, 18, 19}},
+	{Discover, 644, 649, []int{16, 17, 18, 19}},
+	{Discover, 65, 65, []int{16, 17, 18, 19}},
+	{UnionPay, 62, 62, []int{16, 17, 18, 19}},
+	{Maestro, 6304, 6304, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 6759, 6759, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 6761, 6763, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 50, 50, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{Maestro, 56, 69, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+}
+
+// Normalize removes spaces and dashes from a card number.
+func Normalize(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// DetectBrand returns the brand of a card number, or Unknown.
+func DetectBrand(number string) Brand {
+	if r := findRange(Normalize(number)); r != nil {
+		return r.Brand
+	}
+	return Unknown
+}
+
+func findRange(number string) *brandRange {
+	for i := range brandRanges {
+		r := &brandRanges[i]
+		digits := len(strconv.Itoa(r.From))
+		if len(number) < digits {
+			continue
+		}
+		prefix, err := strconv.Atoi(number[:digits])
+		if err == nil && prefix >= r.From && prefix <= r.To {
+			return r
+		}
+	}
+	return nil
+}
+
+// Luhn reports whether the card number passes the Luhn checksum.
+func Luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return len(number) > 0 && sum%10 == 0
+}
+
+// ValidateNumber checks that the card number has only digits,
+// a valid length for its brand and passes the Luhn checksum.
+func ValidateNumber(number string) error {
+	number = Normalize(number)
+	if number == "" {
+		return ErrNumberMissing
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return ErrInvalidNumber
+		}
+	}
+	if len(number) < minLength || len(number) > maxLength {
+		return ErrInvalidLength
+	}
+	if !Luhn(number) {
+		return ErrChecksum
+	}
+	if r := findRange(number); r != nil && !containsInt(r.Lengths, len(number)) {
+		return ErrInvalidLength
+	}
+	return nil
+}
+
+// LastFour returns the last four digits of the card number.
+func LastFour(number string) string {
+	number = Normalize(number)
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
+
+// NormalizeYear turns a two digit year into a four digit year, 22 becomes 2022.
+// Four digit years and 0 (a missing year) are returned as they are.
+func NormalizeYear(year int) int {
+	if year > 0 && year < 100 {
+		return 2000 + year
+	}
+	return year
+}
+
+// ValidateExpiry checks the expiration month and year, two or four digit years are accepted.
+// A card is valid until the end of its expiration month.
+func ValidateExpiry(month, year int, now time.Time) error {
+	return Expiry{Month: month, Year: year}.Validate(now)
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
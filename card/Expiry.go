@@ -0,0 +1,84 @@
+package card
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidExpiry is returned by ParseMMYY when the value is not four digits.
+var ErrInvalidExpiry = errors.New("Expiration must be in the MMYY format")
+
+// Expiry is the expiration date of a card.
+// The SOAP API expects it as MMYY (0524) and ValitorPay
+// expects the month and a four digit year (5 and 2024).
+type Expiry struct {
+	Month int
+	Year  int
+}
+
+// NewExpiry creates an Expiry from a month and a two or four digit year.
+func NewExpiry(month, year int) Expiry {
+	return Expiry{Month: month, Year: NormalizeYear(year)}
+}
+
+// ParseMMYY parses an expiration date in the MMYY format, "0524" is May 2024.
+func ParseMMYY(value string) (Expiry, error) {
+	if len(value) != 4 {
+		return Expiry{}, ErrInvalidExpiry
+	}
+	month, err := strconv.Atoi(value[:2])
+	if err != nil {
+		return Expiry{}, ErrInvalidExpiry
+	}
+	year, err := strconv.Atoi(value[2:])
+	if err != nil {
+		return Expiry{}, ErrInvalidExpiry
+	}
+	e := NewExpiry(month, year)
+	if month < 1 || month > 12 {
+		return e, ErrInvalidMonth
+	}
+	return e, nil
+}
+
+// Validate checks the month and year and that the card has not expired at now.
+// A card is valid until the end of its expiration month.
+func (e Expiry) Validate(now time.Time) error {
+	switch {
+	case e.Month == 0 && e.Year == 0:
+		return ErrExpirationMissing
+	case e.Month == 0:
+		return ErrExpirationMonth
+	case e.Year == 0:
+		return ErrExpirationYear
+	}
+
+	if e.Month < 1 || e.Month > 12 {
+		return ErrInvalidMonth
+	}
+	year := NormalizeYear(e.Year)
+	if year < 2000 || year > 2099 {
+		return ErrInvalidYear
+	}
+	if year < now.Year() || (year == now.Year() && e.Month < int(now.Month())) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// FourDigitYear returns the year with four digits, 24 becomes 2024.
+func (e Expiry) FourDigitYear() int {
+	return NormalizeYear(e.Year)
+}
+
+// MMYY returns the expiration date in the format used by Gildistimi and NyrGildistimi, May 2024 becomes "0524".
+func (e Expiry) MMYY() string {
+	return fmt.Sprintf("%02d%02d", e.Month, e.FourDigitYear()%100)
+}
+
+// String returns the expiration date as MM/YY.
+func (e Expiry) String() string {
+	return fmt.Sprintf("%02d/%02d", e.Month, e.FourDigitYear()%100)
+}
@@ -0,0 +1,225 @@
+// Package cassette records Valitor traffic to a file and replays it in tests.
+//
+// A Recorder is an http.RoundTripper, pass it to a service with valitor.WithTransport.
+// Card numbers, CVCs, passwords and API keys are scrubbed with helpers.Scrub
+// before anything is written to disk.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/opensourcez/go-valitor/helpers"
+)
+
+// ErrNoInteraction is returned in Replay mode when the cassette has no matching request left.
+var ErrNoInteraction = errors.New("Cassette has no matching interaction")
+
+// Mode ...
+type Mode int
+
+const (
+	// Replay answers every request from the cassette and never touches the network.
+	Replay Mode = iota
+	// Record sends every request to valitor and adds it to the cassette.
+	Record
+	// ReplayOrRecord replays if the cassette file exists and records if it does not.
+	ReplayOrRecord
+)
+
+// Interaction is one request and the response valitor gave to it.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request ...
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Response ...
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Cassette is the file format, a list of interactions in the order they happened.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder ...
+type Recorder struct {
+	// Transport sends the requests in Record mode, http.DefaultTransport is used if it is nil.
+	Transport http.RoundTripper
+
+	path     string
+	mode     Mode
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a recorder for the cassette at path.
+// In Replay mode the file must exist, in Record mode it is overwritten by Save.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	if mode == ReplayOrRecord {
+		r.mode = Record
+		if _, err := os.Stat(path); err == nil {
+			r.mode = Replay
+		}
+	}
+	if r.mode != Replay {
+		return r, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("Cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Mode returns Replay or Record, ReplayOrRecord has been resolved by New.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions returns the interactions in the cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save writes the recorded interactions to the cassette file.
+// It does nothing in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == Replay {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, data, 0o644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	request := Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: helpers.ScrubHeader(req.Header),
+		Body:   helpers.ScrubString(string(body)),
+	}
+	if r.mode == Replay {
+		return r.replay(req, request)
+	}
+	return r.record(req, request)
+}
+
+func (r *Recorder) record(req *http.Request, request Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: request,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     helpers.ScrubHeader(resp.Header),
+			Body:       helpers.ScrubString(string(body)),
+		},
+	})
+	return resp, nil
+}
+
+// replay returns the first unused interaction with the same method, URL and body.
+// Bodies often contain generated ids, so if no body matches the first unused
+// interaction with the same method and URL is used instead.
+func (r *Recorder) replay(req *http.Request, request Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request.Method != request.Method || interaction.Request.URL != request.URL {
+			continue
+		}
+		if interaction.Request.Body == request.Body {
+			match = i
+			break
+		}
+		if match == -1 {
+			match = i
+		}
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, request.Method, request.URL)
+	}
+	r.used[match] = true
+
+	response := r.cassette.Interactions[match].Response
+	header := response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(response.Body))),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}, nil
+}
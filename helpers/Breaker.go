@@ -0,0 +1,281 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// =====================================================
+//
+// CIRCUIT BREAKER AND BULKHEAD
+//
+// When valitor is down every call would wait for the HTTP timeout.
+// A CircuitBreaker keeps track of each endpoint, the SOAP URL or a
+// ValitorPay path, and fails calls at once with ErrCircuitOpen after
+// too many failures in a row. After OpenTimeout a few probe calls
+// are let through, if one succeeds the circuit closes again.
+// MaxConcurrent limits how many calls can wait on one endpoint.
+//
+// =====================================================
+
+// ErrCircuitOpen is matched by every *CircuitOpenError, use it with errors.Is.
+var ErrCircuitOpen = errors.New("Circuit open")
+
+// ErrBulkheadFull is returned when MaxConcurrent calls to an endpoint are already running.
+var ErrBulkheadFull = errors.New("Too many concurrent calls to valitor")
+
+// CircuitOpenError ...
+// The call was not sent because the circuit for Endpoint is open.
+type CircuitOpenError struct {
+	Endpoint string
+	// RetryAfter is how long until a probe call is let through.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return "Circuit open for " + e.Endpoint + ", retry after " + e.RetryAfter.String()
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) true.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CallOutcome ...
+// How a call that got through Acquire ended, passed to its release func.
+type CallOutcome int
+
+const (
+	// CallSucceeded resets the failures in a row and closes a half open circuit.
+	CallSucceeded CallOutcome = iota
+	// CallFailed counts against the endpoint and opens a half open circuit again.
+	CallFailed
+	// CallAbandoned only frees the slot or probe, the caller gave up before valitor answered
+	// so the call says nothing about the endpoint.
+	CallAbandoned
+)
+
+// EndpointOutcome returns the CallOutcome of a call that reached the network.
+// Every failure worth a retry is CallFailed, a timeout, a 5xx, a 429 or a retryable error code,
+// unless ctx was canceled by the caller, then it is CallAbandoned. Declines and other answers
+// about the card are CallSucceeded. Errors before the request is sent must not be reported,
+// release such calls with CallAbandoned.
+func EndpointOutcome(ctx context.Context, failure Failure) CallOutcome {
+	if failure == FailureNone {
+		return CallSucceeded
+	}
+	if ctx.Err() != nil {
+		return CallAbandoned
+	}
+	return CallFailed
+}
+
+// CircuitState ...
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every call with a *CircuitOpenError.
+	CircuitOpen
+	// CircuitHalfOpen lets HalfOpenProbes calls through to see if the endpoint is back.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	}
+	return "closed"
+}
+
+// CircuitBreakerSettings ...
+// Zero values are replaced with the defaults below.
+type CircuitBreakerSettings struct {
+	// FailureThreshold failures in a row open the circuit, 5 by default.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing, 30 seconds by default.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many calls are let through at once while probing, 1 by default.
+	HalfOpenProbes int
+	// MaxConcurrent limits the calls running against one endpoint, 0 means no limit.
+	MaxConcurrent int
+	// MaxWait is how long a call waits for one of the MaxConcurrent slots, 0 fails at once with ErrBulkheadFull.
+	MaxWait time.Duration
+}
+
+// CircuitBreaker ...
+// One CircuitBreaker can be shared by many services, every endpoint has its own circuit.
+// A nil CircuitBreaker lets every call through.
+type CircuitBreaker struct {
+	settings  CircuitBreakerSettings
+	mu        sync.Mutex
+	endpoints map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+	slots    chan struct{}
+}
+
+// NewCircuitBreaker creates a CircuitBreaker, zero settings are replaced with the defaults.
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{
+		settings:  settings,
+		endpoints: make(map[string]*circuit),
+	}
+}
+
+// State returns the state of the circuit for endpoint.
+func (cb *CircuitBreaker) State(endpoint string) CircuitState {
+	if cb == nil {
+		return CircuitClosed
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c := cb.circuit(endpoint)
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= cb.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Acquire asks to send a call to endpoint. It fails with a *CircuitOpenError when the circuit
+// is open and with ErrBulkheadFull when no slot is free. Otherwise release must be called
+// when the call is finished, outcome tells the circuit how the endpoint behaved.
+func (cb *CircuitBreaker) Acquire(ctx context.Context, endpoint string) (release func(outcome CallOutcome), err error) {
+	if cb == nil {
+		return func(CallOutcome) {}, nil
+	}
+
+	c, probe, err := cb.allow(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := cb.takeSlot(ctx, c); err != nil {
+		cb.mu.Lock()
+		if probe {
+			c.probes--
+		}
+		cb.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", err, endpoint)
+	}
+
+	var once sync.Once
+	return func(outcome CallOutcome) {
+		once.Do(func() {
+			cb.done(c, probe, outcome)
+			if c.slots != nil {
+				<-c.slots
+			}
+		})
+	}, nil
+}
+
+// circuit must be called with cb.mu held.
+func (cb *CircuitBreaker) circuit(endpoint string) *circuit {
+	c, ok := cb.endpoints[endpoint]
+	if !ok {
+		c = &circuit{}
+		if cb.settings.MaxConcurrent > 0 {
+			c.slots = make(chan struct{}, cb.settings.MaxConcurrent)
+		}
+		cb.endpoints[endpoint] = c
+	}
+	return c
+}
+
+func (cb *CircuitBreaker) allow(endpoint string) (c *circuit, probe bool, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c = cb.circuit(endpoint)
+
+	if c.state == CircuitOpen {
+		elapsed := time.Since(c.openedAt)
+		if elapsed < cb.settings.OpenTimeout {
+			return nil, false, &CircuitOpenError{Endpoint: endpoint, RetryAfter: cb.settings.OpenTimeout - elapsed}
+		}
+		c.state = CircuitHalfOpen
+		c.probes = 0
+	}
+	if c.state == CircuitHalfOpen {
+		if c.probes >= cb.settings.HalfOpenProbes {
+			return nil, false, &CircuitOpenError{Endpoint: endpoint}
+		}
+		c.probes++
+		return c, true, nil
+	}
+	return c, false, nil
+}
+
+func (cb *CircuitBreaker) takeSlot(ctx context.Context, c *circuit) error {
+	if c.slots == nil {
+		return nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if cb.settings.MaxWait <= 0 {
+		return ErrBulkheadFull
+	}
+
+	timer := time.NewTimer(cb.settings.MaxWait)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (cb *CircuitBreaker) done(c *circuit, probe bool, outcome CallOutcome) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe {
+		c.probes--
+	}
+	switch outcome {
+	case CallAbandoned:
+		return
+	case CallFailed:
+		if probe {
+			c.state = CircuitOpen
+			c.openedAt = time.Now()
+			return
+		}
+		c.failures++
+		if c.state == CircuitClosed && c.failures >= cb.settings.FailureThreshold {
+			c.state = CircuitOpen
+			c.openedAt = time.Now()
+		}
+	default:
+		if probe {
+			c.state = CircuitClosed
+		}
+		c.failures = 0
+	}
+}
@@ -0,0 +1,72 @@
+package helpers
+
+// =====================================================
+//
+// ERROR CATEGORIES
+//
+// jsoncore maps ValitorPay response codes to a sentinel error
+// and a Category in its catalog, the Category tells retries,
+// logs, metrics and the caller who can fix it. xmlcore has no
+// catalog, see ErrorsXML.go.
+//
+// =====================================================
+
+// Category ...
+// Tells the caller who can fix the problem.
+type Category int
+
+const (
+	// CategoryUnknown is used for codes that are not in the catalog.
+	CategoryUnknown Category = iota
+	// CategoryRetryable problems are temporary and the same request can be sent again later.
+	CategoryRetryable
+	// CategoryCardholder problems should be shown to the cardholder, for example a declined card.
+	CategoryCardholder
+	// CategoryMerchantConfig problems are in our own settings or request and need a developer.
+	CategoryMerchantConfig
+)
+
+func (c Category) String() string {
+	switch c {
+	case CategoryRetryable:
+		return "retryable"
+	case CategoryCardholder:
+		return "cardholder"
+	case CategoryMerchantConfig:
+		return "merchant-config"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable reports whether the same request can be sent again later.
+func (c Category) Retryable() bool {
+	return c == CategoryRetryable
+}
+
+// CardholderFacing reports whether the error should be shown to the cardholder.
+func (c Category) CardholderFacing() bool {
+	return c == CategoryCardholder
+}
+
+// MerchantConfig reports whether the error is caused by our own settings or request.
+func (c Category) MerchantConfig() bool {
+	return c == CategoryMerchantConfig
+}
+
+// CatalogEntry is the sentinel error and category of one valitor error code.
+type CatalogEntry struct {
+	Err      error
+	Category Category
+}
+
+// ErrorDetails is what logs, spans and metrics record about a Villunumer or responseCode.
+// xmlcore and jsoncore extract it from their own error types.
+type ErrorDetails struct {
+	// Code is the Villunumer or responseCode.
+	Code    string
+	Message string
+	// LogID is the VilluLogID valitor support asks for, JSON answers have none.
+	LogID    string
+	Category Category
+}
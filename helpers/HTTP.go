@@ -2,81 +2,93 @@ package helpers
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"time"
 )
 
-var DebugMode bool
+// DefaultTimeout is the timeout used by DefaultClient.
+const DefaultTimeout = 30 * time.Second
 
-func sendRequest(envelope string, method string, url string) ([]byte, error) {
+// DefaultClient is used whenever a service has not been given its own *http.Client.
+var DefaultClient = &http.Client{Timeout: DefaultTimeout}
 
-	client := &http.Client{}
-	// build a new request, but not doing the POST yet
-	if DebugMode {
-		log.Println(method, " -> ", url, " -> Data:", envelope)
+func clientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return DefaultClient
 	}
+	return client
+}
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer([]byte(envelope)))
+func sendRequest(ctx context.Context, client *http.Client, envelope string, method string, url string, header http.Header) ([]byte, int, error) {
+
+	// build a new request, but not doing the POST yet
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer([]byte(envelope)))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// you can then set the Header here
 	// I think the content-type should be "application/xml" like json...
-	req.Header.Add("Content-Type", "text/xml; charset=utf-8")
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
 	// now POST it
-	resp, err := client.Do(req)
+	resp, err := clientOrDefault(client).Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	defer resp.Body.Close()
 
 	// read the response body to a variable
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-	if DebugMode {
-
-		log.Println(method, " -> ", url, " -> Response Code: ", resp.StatusCode)
-		bodyString := string(bodyBytes)
-		log.Println(method, " -> ", url, " -> Body: ", bodyString)
+		return nil, resp.StatusCode, err
 	}
-
-	//print raw response body for debugging purposes
-	return bodyBytes, nil
+	return bodyBytes, resp.StatusCode, nil
 }
 
-func Send(url, method, body string) (results []byte, err error) {
-	results, requestError := sendRequest(body, "POST", url)
+// Send posts a SOAP envelope using the given client and returns the body and HTTP status code.
+// The given headers are added to the request, this is where the trace context goes.
+// If client is nil DefaultClient is used.
+func Send(ctx context.Context, client *http.Client, url, method, body string, header http.Header) (results []byte, statusCode int, err error) {
+	results, statusCode, requestError := sendRequest(ctx, client, body, method, url, header)
 	if requestError != nil {
-		return results, requestError
+		return results, statusCode, requestError
 	}
 
-	return results, nil
+	return results, statusCode, nil
 }
 
-func SendJSON(data []byte, method string, url string) ([]byte, int, error) {
+// SendJSON sends a JSON payload using the given client.
+// The given headers are added to the request, this is where the API key goes.
+// If client is nil DefaultClient is used.
+func SendJSON(ctx context.Context, client *http.Client, data []byte, method string, url string, header http.Header) ([]byte, int, error) {
 
-	client := &http.Client{}
 	// build a new request, but not doing the POST yet
-	log.Println("SENDING TO:", method, url)
-	log.Println(string(data))
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// you can then set the Header here
 	// I think the content-type should be "application/xml" like json...
-	req.Header.Add("valitor-api-version", "2.0")
-	req.Header.Add("Authorization", "APIKey VPUAT.avI9NMNHxj+X2JJn16ckUwZ+wOUXo8btfSBYvQpzogg=")
-	req.Header.Add("Content-Type", "application/json; charset=utf-8")
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	// now POST it
-	resp, err := client.Do(req)
+	resp, err := clientOrDefault(client).Do(req)
 	if err != nil {
 		return nil, 0, err
 	}
+	defer resp.Body.Close()
 
 	// read the response body to a variable
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
@@ -84,10 +96,5 @@ func SendJSON(data []byte, method string, url string) ([]byte, int, error) {
 		return nil, resp.StatusCode, err
 	}
 
-	log.Println("body!")
-	log.Println(resp.StatusCode)
-	bodyString := string(bodyBytes)
-	log.Println(bodyString)
-	//print raw response body for debugging purposes
 	return bodyBytes, resp.StatusCode, nil
 }
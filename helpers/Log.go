@@ -0,0 +1,197 @@
+package helpers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// =====================================================
+//
+// LOGGING
+//
+// Everything this module logs goes through Logger(), which
+// wraps the configured handler in a RedactingHandler, so card
+// numbers, CVCs, passwords and API keys never reach a log line.
+//
+// =====================================================
+
+var logger atomic.Pointer[slog.Logger]
+
+// sensitiveKeys are attribute keys whose value is always replaced with Redacted.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"lykilord":      true,
+	"cvc":           true,
+	"cvv":           true,
+	"oryggisnumer":  true,
+	"apikey":        true,
+	"api_key":       true,
+	"authorization": true,
+}
+
+// cardNumberKeys are attribute keys whose value is always masked with MaskPAN,
+// even when it is not a valid card number.
+var cardNumberKeys = map[string]bool{
+	"cardnumber": true,
+	"kortnumer":  true,
+	"pan":        true,
+}
+
+// SetLogger sets the logger used by the module, nil goes back to slog.Default().
+// The handler of l is wrapped in a RedactingHandler.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		logger.Store(nil)
+		return
+	}
+	logger.Store(slog.New(NewRedactingHandler(l.Handler())))
+}
+
+// Logger returns the logger set with SetLogger, or slog.Default() wrapped in a RedactingHandler.
+func Logger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.New(NewRedactingHandler(slog.Default().Handler()))
+}
+
+// RedactingHandler is a slog.Handler that scrubs the message and every attribute
+// before passing the record on. Card numbers are masked to the first six and last
+// four digits, passwords, CVCs and API keys are replaced with Redacted.
+// Values of other types than strings, errors, headers and bodies are replaced with
+// Redacted too, implement slog.LogValuer to log them, like xmlcore.Card and jsoncore.Card do.
+type RedactingHandler struct {
+	next slog.Handler
+}
+
+// NewRedactingHandler wraps next, a RedactingHandler is never wrapped twice.
+func NewRedactingHandler(next slog.Handler) *RedactingHandler {
+	if h, ok := next.(*RedactingHandler); ok {
+		return h
+	}
+	return &RedactingHandler{next: next}
+}
+
+// Enabled implements slog.Handler.
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *RedactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	scrubbed := slog.NewRecord(record.Time, record.Level, ScrubString(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		scrubbed.AddAttrs(RedactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, scrubbed)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = RedactAttr(attr)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name)}
+}
+
+// RedactAttr scrubs a single attribute, groups are scrubbed recursively.
+// The key decides first, so a CVC or card number is redacted whatever its type.
+func RedactAttr(attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	if sensitiveKeys[key] {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	value := attr.Value.Resolve()
+	if cardNumberKeys[key] {
+		if value.Kind() == slog.KindString {
+			return slog.String(attr.Key, MaskPAN(value.String()))
+		}
+		return slog.String(attr.Key, Redacted)
+	}
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, ScrubString(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, a := range group {
+			redacted[i] = RedactAttr(a)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case http.Header:
+			return slog.Any(attr.Key, ScrubHeader(v))
+		case []byte:
+			return slog.String(attr.Key, ScrubString(string(v)))
+		case error:
+			return slog.String(attr.Key, ScrubString(v.Error()))
+		default:
+			return slog.String(attr.Key, Redacted)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+// ServiceLogger returns l wrapped in a RedactingHandler, or Logger() if l is nil.
+func ServiceLogger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return Logger()
+	}
+	return slog.New(NewRedactingHandler(l.Handler()))
+}
+
+// LogResult writes one record per call to valitor.
+// Successful calls are logged at Info, valitor errors and declines at Warn and system errors at Error.
+// attrs are added to the record, for example the transactionLifecycleId of a JSON call.
+func LogResult(ctx context.Context, logger *slog.Logger, duration time.Duration, statusCode int, err error, details *ErrorDetails, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{
+		slog.Duration("duration", duration),
+		slog.Int("status", statusCode),
+	}, attrs...)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		logger.LogAttrs(ctx, slog.LevelError, "valitor call failed", attrs...)
+		return
+	}
+
+	if details != nil {
+		attrs = append(attrs,
+			slog.String("error_code", details.Code),
+			slog.String("error_message", details.Message),
+			slog.String("error_category", details.Category.String()),
+		)
+		if details.LogID != "" {
+			attrs = append(attrs, slog.String("error_log_id", details.LogID))
+		}
+		logger.LogAttrs(ctx, slog.LevelWarn, "valitor call returned an error", attrs...)
+		return
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "valitor call", attrs...)
+}
+
+// LogRetry writes a record at Warn before a failed call is sent again.
+func LogRetry(ctx context.Context, logger *slog.Logger, attempt int, failure Failure, wait time.Duration, statusCode int, err error) {
+	attrs := []slog.Attr{
+		slog.Int("attempt", attempt),
+		slog.String("failure", failure.String()),
+		slog.Duration("wait", wait),
+		slog.Int("status", statusCode),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, slog.LevelWarn, "valitor call retried", attrs...)
+}
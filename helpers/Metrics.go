@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/opensourcez/go-valitor/metrics"
+)
+
+// Outcome returns the metrics.Outcome* value of a finished call.
+func Outcome(err error, details *ErrorDetails) string {
+	switch {
+	case err != nil:
+		return metrics.OutcomeSystemError
+	case details != nil:
+		return metrics.OutcomeValitorError
+	default:
+		return metrics.OutcomeSuccess
+	}
+}
+
+// Observe records a finished call in collector, nothing is recorded if it is nil.
+func Observe(collector *metrics.Collector, core, operation string, duration time.Duration, statusCode int, err error, details *ErrorDetails) {
+	if collector == nil {
+		return
+	}
+	call := metrics.Call{
+		Core:       core,
+		Operation:  operation,
+		Outcome:    Outcome(err, details),
+		StatusCode: statusCode,
+		Duration:   duration,
+	}
+	if err == nil && details != nil {
+		call.ErrorCode = details.Code
+	}
+	collector.Observe(call)
+}
@@ -0,0 +1,211 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// =====================================================
+//
+// RETRIES
+//
+// A failed call is only sent again when that can not charge the
+// cardholder twice. Operations that only read or update stored
+// data are Safe, and payments with a ReferenceNumber or
+// TransactionLifecycleID are Keyed, both are retried after any
+// transient failure. Other payments are never retried when valitor
+// might have processed them, for example after a timeout, they
+// fail with ErrOutcomeUnknown and the transaction status must be
+// checked before the payment is sent again.
+//
+// =====================================================
+
+// ErrOutcomeUnknown is wrapped around the error when a payment failed in a way
+// that valitor might still have processed it, for example a timeout.
+var ErrOutcomeUnknown = errors.New("Outcome unknown, check the transaction status before sending it again")
+
+// Idempotency tells the retry policy what it costs to send an operation twice.
+type Idempotency int
+
+const (
+	// IdempotencyNone operations, like FaHeimild, are only retried when the request never reached valitor.
+	IdempotencyNone Idempotency = iota
+	// IdempotencyKeyed operations carry a ReferenceNumber or TransactionLifecycleID that lets valitor
+	// spot a duplicate, they are retried after any transient failure, timeouts included.
+	IdempotencyKeyed
+	// IdempotencySafe operations, like FaSyndarkortnumer, are retried after any transient failure.
+	IdempotencySafe
+)
+
+// Failure classifies how a single attempt failed.
+type Failure int
+
+const (
+	// FailureNone means the attempt succeeded, or failed in a way that sending it again will not fix.
+	FailureNone Failure = iota
+	// FailureNotSent means the request never reached valitor, for example the connection was refused.
+	FailureNotSent
+	// FailureRejected means valitor answered that the request was not processed and can be sent again later.
+	FailureRejected
+	// FailureUnknown means valitor might have processed the request, for example after a timeout.
+	FailureUnknown
+)
+
+func (f Failure) String() string {
+	switch f {
+	case FailureNotSent:
+		return "not_sent"
+	case FailureRejected:
+		return "rejected"
+	case FailureUnknown:
+		return "unknown"
+	}
+	return "none"
+}
+
+// ClassifyError classifies an error returned by Send or SendJSON.
+func ClassifyError(err error) Failure {
+	if err == nil {
+		return FailureNone
+	}
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return FailureNotSent
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) && opError.Op == "dial" {
+		return FailureNotSent
+	}
+	return FailureUnknown
+}
+
+// ClassifyStatus classifies an HTTP status code from valitor.
+func ClassifyStatus(statusCode int) Failure {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return FailureRejected
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return FailureUnknown
+	}
+	return FailureNone
+}
+
+// RetryPolicy ...
+// Decides if and when a failed call is sent again. A nil policy never retries.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt, 1 or less never retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt, it is multiplied by
+	// Multiplier for every attempt after that up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each wait by up to this fraction, 0.2 waits between 80% and 120%.
+	Jitter float64
+}
+
+// DefaultRetryPolicy tries three times, waiting 200ms and then 400ms.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// ShouldRetry reports whether an operation that failed with failure may be sent again.
+func (p *RetryPolicy) ShouldRetry(idempotency Idempotency, failure Failure) bool {
+	if p == nil {
+		return false
+	}
+	switch failure {
+	case FailureNotSent:
+		return true
+	case FailureRejected, FailureUnknown:
+		return idempotency >= IdempotencyKeyed
+	}
+	return false
+}
+
+// Backoff returns the wait after the given attempt, the first attempt is 1.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(wait)
+}
+
+// Do runs attempt until it succeeds, the policy gives up or ctx is done, and returns
+// the failure of the last attempt. onRetry is called before every wait.
+func (p *RetryPolicy) Do(ctx context.Context, idempotency Idempotency, attempt func() Failure, onRetry func(attempt int, failure Failure, wait time.Duration)) Failure {
+	for n := 1; ; n++ {
+		failure := attempt()
+		if !p.ShouldRetry(idempotency, failure) || n >= p.MaxAttempts || ctx.Err() != nil {
+			return failure
+		}
+
+		wait := p.Backoff(n)
+		if onRetry != nil {
+			onRetry(n, failure, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return failure
+		case <-timer.C:
+		}
+	}
+}
+
+// OutcomeUnknown wraps err in ErrOutcomeUnknown when failure is FailureUnknown
+// and the operation is IdempotencyNone, so it was not sent again.
+func OutcomeUnknown(err error, idempotency Idempotency, failure Failure) error {
+	if err == nil || failure != FailureUnknown || idempotency != IdempotencyNone {
+		return err
+	}
+	return &outcomeUnknownError{err: err}
+}
+
+type outcomeUnknownError struct {
+	err error
+}
+
+func (e *outcomeUnknownError) Error() string {
+	return ErrOutcomeUnknown.Error() + ": " + e.err.Error()
+}
+
+// Unwrap returns ErrOutcomeUnknown and the original error, so errors.Is and errors.As find both.
+func (e *outcomeUnknownError) Unwrap() []error {
+	return []error{ErrOutcomeUnknown, e.err}
+}
+
+// Snapshot saves the value v points to and returns a func that puts it back,
+// so every attempt unmarshals into the same response the caller started with.
+func Snapshot(v interface{}) func() {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return func() {}
+	}
+	saved := reflect.New(target.Elem().Type()).Elem()
+	saved.Set(target.Elem())
+	return func() {
+		target.Elem().Set(saved)
+	}
+}
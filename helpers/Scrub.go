@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/opensourcez/go-valitor/card"
+)
+
+// =====================================================
+//
+// SCRUBBING
+//
+// Card numbers, CVCs, passwords and API keys must never be
+// written to disk or logs. The functions below remove them
+// from SOAP envelopes, JSON bodies and HTTP headers.
+//
+// =====================================================
+
+// Redacted replaces secrets that are removed completely.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are replaced with Redacted by ScrubHeader.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+var (
+	// <Kortnumer>5304259906522887</Kortnumer> and "cardNumber": "5304259906522887".
+	xmlCardNumbers  = regexp.MustCompile(`(<(?:\w+:)?Kortnumer>)([^<]*)(</(?:\w+:)?Kortnumer>)`)
+	jsonCardNumbers = regexp.MustCompile(`(?i)("cardNumber"\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+	// <Lykilord>secret</Lykilord>, with or without a namespace prefix.
+	xmlSecrets = regexp.MustCompile(`(<(?:\w+:)?(?:Lykilord|Oryggisnumer)>)[^<]*(</(?:\w+:)?(?:Lykilord|Oryggisnumer)>)`)
+	// "cvc": "123" or "cvc": 123, keys are matched without case.
+	jsonSecrets = regexp.MustCompile(`(?i)("(?:cvc|cvv|cvc2|password|apiKey|api_key)"\s*:\s*)(?:"(?:[^"\\]|\\.)*"|-?\d+)`)
+	// Authorization values such as "APIKey secret" or "Bearer secret" in free text.
+	credentials = regexp.MustCompile(`(?i)\b(APIKey|Bearer|Basic)\s+[^\s"',\]}]+`)
+	// Runs of 12 to 19 digits, possibly split by single spaces or dashes.
+	panCandidates = regexp.MustCompile(`\d(?:[ -]?\d){11,18}`)
+)
+
+// MaskPAN keeps the first six and last four digits of a card number and replaces the rest with '*'.
+// Numbers too short for that keep only the last four digits.
+func MaskPAN(number string) string {
+	number = card.Normalize(number)
+	switch {
+	case len(number) <= 4:
+		return strings.Repeat("*", len(number))
+	case len(number) <= 10:
+		return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+	}
+	return number[:6] + strings.Repeat("*", len(number)-10) + number[len(number)-4:]
+}
+
+// ScrubString masks every card number and removes passwords, CVCs and API keys from s.
+// Kortnumer and cardNumber fields are always masked, elsewhere a card number is any
+// run of digits with a known brand that passes card.ValidateNumber.
+func ScrubString(s string) string {
+	s = maskFields(xmlCardNumbers, s)
+	s = maskFields(jsonCardNumbers, s)
+	s = xmlSecrets.ReplaceAllString(s, "${1}"+Redacted+"${2}")
+	s = jsonSecrets.ReplaceAllString(s, `${1}"`+Redacted+`"`)
+	s = credentials.ReplaceAllString(s, "${1} "+Redacted)
+	return panCandidates.ReplaceAllStringFunc(s, func(candidate string) string {
+		if card.DetectBrand(candidate) == card.Unknown || card.ValidateNumber(candidate) != nil {
+			return candidate
+		}
+		return MaskPAN(candidate)
+	})
+}
+
+// maskFields masks the second group of every match of re, the first and third are kept.
+// Values of four digits or less are kept, FaSidustuFjora returns only the last four in Kortnumer.
+func maskFields(re *regexp.Regexp, s string) string {
+	return re.ReplaceAllStringFunc(s, func(field string) string {
+		parts := re.FindStringSubmatch(field)
+		if len(card.Normalize(parts[2])) <= 4 {
+			return field
+		}
+		return parts[1] + MaskPAN(parts[2]) + parts[3]
+	})
+}
+
+// Scrub is ScrubString for request and response bodies.
+func Scrub(body []byte) []byte {
+	return []byte(ScrubString(string(body)))
+}
+
+// ScrubHeader returns a copy of header with credentials and cookies replaced by Redacted.
+func ScrubHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, key := range sensitiveHeaders {
+		if _, ok := scrubbed[http.CanonicalHeaderKey(key)]; ok {
+			scrubbed.Set(key, Redacted)
+		}
+	}
+	return scrubbed
+}
@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/opensourcez/go-valitor/money"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// =====================================================
+//
+// TRACING
+//
+// Every call to valitor gets a client span, and the trace
+// context is sent to valitor in the traceparent header.
+// Without a TracerProvider the global one from otel is used,
+// which does nothing until the application sets one.
+//
+// =====================================================
+
+// Tracer returns the tracer called name from provider, or from the otel global if provider is nil.
+func Tracer(provider trace.TracerProvider, name string) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(name)
+}
+
+// Propagator returns propagator, or the otel global if it is nil.
+func Propagator(propagator propagation.TextMapPropagator) propagation.TextMapPropagator {
+	if propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+	return propagator
+}
+
+// StartSpan starts the client span for operation with attrs, the currency and the amount in minor units.
+func StartSpan(ctx context.Context, tracer trace.Tracer, operation string, amount money.Money, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{attribute.String("valitor.operation", operation)}, attrs...)
+	if amount.Currency != "" {
+		attrs = append(attrs, attribute.String("valitor.currency", amount.Currency.String()))
+	}
+	if amount.Amount != 0 {
+		attrs = append(attrs, attribute.Int64("valitor.amount", amount.MinorUnits()))
+	}
+	return tracer.Start(ctx, "valitor "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// EndSpan records the outcome of the call and ends the span.
+func EndSpan(span trace.Span, statusCode int, err error, details *ErrorDetails) {
+	defer span.End()
+	if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+	span.SetAttributes(attribute.String("valitor.outcome", Outcome(err, details)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	if details != nil {
+		span.SetAttributes(attribute.String("valitor.error_code", details.Code))
+		if details.LogID != "" {
+			span.SetAttributes(attribute.String("valitor.error_log_id", details.LogID))
+		}
+		span.SetStatus(codes.Error, details.Message)
+	}
+}
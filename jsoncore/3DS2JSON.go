@@ -0,0 +1,230 @@
+package jsoncore
+
+import (
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// =====================================================
+//
+// EMV 3-D SECURE (3DS2)
+//
+// The issuer decides from BrowserInfo whether the cardholder has to
+// be challenged. Fill it from the checkout request with
+// BrowserInfoFromRequest, screen size, color depth and time zone can
+// only be read with JavaScript, post them with the checkout form as
+// browserColorDepth, browserScreenHeight, browserScreenWidth,
+// browserTZ and browserJavaEnabled.
+//
+// Documentation: https://uat.valitorpay.com/index.html#operation/CardVerification
+//
+// =====================================================
+
+// ChallengeWindowSize is the size of the challenge page the ACS shows.
+type ChallengeWindowSize string
+
+// The window sizes defined by EMV 3-D Secure.
+const (
+	ChallengeWindow250x400    ChallengeWindowSize = "01"
+	ChallengeWindow390x400    ChallengeWindowSize = "02"
+	ChallengeWindow500x600    ChallengeWindowSize = "03"
+	ChallengeWindow600x400    ChallengeWindowSize = "04"
+	ChallengeWindowFullScreen ChallengeWindowSize = "05"
+)
+
+// Valid reports whether s is one of the EMV window sizes.
+func (s ChallengeWindowSize) Valid() bool {
+	switch s {
+	case ChallengeWindow250x400, ChallengeWindow390x400, ChallengeWindow500x600, ChallengeWindow600x400, ChallengeWindowFullScreen:
+		return true
+	}
+	return false
+}
+
+// TransStatus is the EMV 3-D Secure transaction status.
+type TransStatus string
+
+// The transaction statuses defined by EMV 3-D Secure.
+const (
+	// TransStatusAuthenticated means the cardholder was authenticated without a challenge.
+	TransStatusAuthenticated TransStatus = "Y"
+	// TransStatusAttempted means authentication could not be done but the attempt counts as proof.
+	TransStatusAttempted TransStatus = "A"
+	// TransStatusChallenge means the cardholder has to be sent to the ACS.
+	TransStatusChallenge TransStatus = "C"
+	// TransStatusNotAuthenticated, TransStatusUnavailable and TransStatusRejected fail the verification.
+	TransStatusNotAuthenticated TransStatus = "N"
+	TransStatusUnavailable      TransStatus = "U"
+	TransStatusRejected         TransStatus = "R"
+)
+
+// Authenticated reports whether the status can be used for a payment.
+func (s TransStatus) Authenticated() bool {
+	return s == TransStatusAuthenticated || s == TransStatusAttempted
+}
+
+// BrowserInfo ...
+// The cardholder's browser, required for 3DS2.
+// Documentation: https://uat.valitorpay.com/index.html#operation/CardVerification
+type BrowserInfo struct {
+	AcceptHeader      string `json:"browserAcceptHeader"`
+	IPAddress         string `json:"browserIP,omitempty"`
+	JavaEnabled       bool   `json:"browserJavaEnabled"`
+	JavaScriptEnabled bool   `json:"browserJavascriptEnabled"`
+	// Language is an IETF BCP 47 tag like is-IS.
+	Language  string `json:"browserLanguage"`
+	UserAgent string `json:"browserUserAgent"`
+	// ColorDepth, ScreenHeight, ScreenWidth and TimeZoneOffset are required when JavaScriptEnabled is true.
+	ColorDepth   int `json:"browserColorDepth,omitempty"`
+	ScreenHeight int `json:"browserScreenHeight,omitempty"`
+	ScreenWidth  int `json:"browserScreenWidth,omitempty"`
+	// TimeZoneOffset is UTC minus local time in minutes, what getTimezoneOffset() returns in JavaScript.
+	TimeZoneOffset      int                 `json:"browserTZ"`
+	ChallengeWindowSize ChallengeWindowSize `json:"challengeWindowSize,omitempty"`
+}
+
+// colorDepths are the values EMV 3-D Secure accepts for browserColorDepth.
+var colorDepths = map[int]bool{1: true, 4: true, 8: true, 15: true, 16: true, 24: true, 32: true, 48: true}
+
+// Validate checks the fields the issuer needs.
+func (b *BrowserInfo) Validate() error {
+	if b.AcceptHeader == "" {
+		return errors.New("Browser Accept Header missing")
+	}
+	if b.UserAgent == "" {
+		return errors.New("Browser User Agent missing")
+	}
+	if b.Language == "" || len(b.Language) > 8 {
+		return errors.New("Browser Language missing or longer than 8 characters")
+	}
+	if b.ChallengeWindowSize != "" && !b.ChallengeWindowSize.Valid() {
+		return errors.New("Challenge Window Size is not between 01 and 05")
+	}
+	if !b.JavaScriptEnabled {
+		return nil
+	}
+	if !colorDepths[b.ColorDepth] {
+		return errors.New("Browser Color Depth is not 1, 4, 8, 15, 16, 24, 32 or 48")
+	}
+	if b.ScreenHeight <= 0 || b.ScreenWidth <= 0 {
+		return errors.New("Browser Screen Size missing")
+	}
+	return nil
+}
+
+// BrowserInfoFromRequest reads BrowserInfo from the cardholder's checkout request.
+// The IP address is taken from RemoteAddr, set IPAddress yourself behind a proxy.
+// JavaScriptEnabled is true when the checkout form posted browserScreenWidth.
+func BrowserInfoFromRequest(r *http.Request) *BrowserInfo {
+	info := &BrowserInfo{
+		AcceptHeader:        r.Header.Get("Accept"),
+		UserAgent:           r.Header.Get("User-Agent"),
+		Language:            firstLanguage(r.Header.Get("Accept-Language")),
+		ChallengeWindowSize: ChallengeWindowFullScreen,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		info.IPAddress = host
+	}
+
+	if r.FormValue("browserScreenWidth") == "" {
+		return info
+	}
+	info.JavaScriptEnabled = true
+	info.JavaEnabled = r.FormValue("browserJavaEnabled") == "true"
+	info.ColorDepth, _ = strconv.Atoi(r.FormValue("browserColorDepth"))
+	info.ScreenHeight, _ = strconv.Atoi(r.FormValue("browserScreenHeight"))
+	info.ScreenWidth, _ = strconv.Atoi(r.FormValue("browserScreenWidth"))
+	info.TimeZoneOffset, _ = strconv.Atoi(r.FormValue("browserTZ"))
+	return info
+}
+
+// firstLanguage returns the first tag of an Accept-Language header, "is-IS,is;q=0.9" gives is-IS.
+func firstLanguage(header string) string {
+	language := strings.TrimSpace(strings.Split(strings.Split(header, ",")[0], ";")[0])
+	if len(language) > 8 {
+		return ""
+	}
+	return language
+}
+
+// VerificationFlow is what to do with a CardVerificationResponse.
+type VerificationFlow int
+
+const (
+	// FlowFailed means the cardholder can not be verified, do not use the card without 3-D Secure.
+	FlowFailed VerificationFlow = iota
+	// FlowFrictionless means the issuer authenticated the cardholder without a challenge, use VerificationData.
+	FlowFrictionless
+	// FlowChallenge means the cardholder has to be sent to the ACS with RedirectForm.
+	FlowChallenge
+)
+
+func (f VerificationFlow) String() string {
+	switch f {
+	case FlowFrictionless:
+		return "frictionless"
+	case FlowChallenge:
+		return "challenge"
+	}
+	return "failed"
+}
+
+// Flow tells frictionless 3DS2 verifications from the ones that need a challenge.
+// 3DS1 verifications are always a challenge.
+func (r *CardVerificationResponse) Flow() VerificationFlow {
+	if r.Err() != nil {
+		return FlowFailed
+	}
+	switch r.TransStatus {
+	case TransStatusAuthenticated, TransStatusAttempted:
+		return FlowFrictionless
+	case TransStatusChallenge:
+		return FlowChallenge
+	case "":
+		if r.PostURL != "" || r.CardVerificationRawResponse != "" {
+			return FlowChallenge
+		}
+	}
+	return FlowFailed
+}
+
+// VerificationData returns the CardVerificationData of a frictionless verification,
+// or ErrMissingVerificationData when the cardholder still has to be authenticated.
+func (r *CardVerificationResponse) VerificationData() (CardVerificationData, error) {
+	if r.Flow() != FlowFrictionless {
+		return CardVerificationData{}, ErrMissingVerificationData
+	}
+	return CardVerificationData{
+		VerifyingEnrollmentResponse:              "Y",
+		PayerAuthenticationResponse:              string(r.TransStatus),
+		CardholderAuthenticationVerificationData: r.AuthenticationValue,
+		ECI:                                      r.ECI,
+		DSTransID:                                r.DSTransID,
+		ThreeDSServerTransID:                     r.ThreeDSServerTransID,
+		MessageVersion:                           r.MessageVersion,
+	}, nil
+}
+
+var methodForm = template.Must(template.New("method").Parse(`<iframe name="threeDSMethod" style="display:none"></iframe>
+<form method="POST" action="{{.ThreeDSMethodURL}}" target="threeDSMethod">
+<input type="hidden" name="threeDSMethodData" value="{{.ThreeDSMethodData}}">
+</form>
+<script>document.forms[document.forms.length-1].submit()</script>
+`))
+
+// MethodForm returns HTML that lets the issuer collect browser data in a hidden iframe,
+// put it on the page before the challenge. It is empty when the issuer has no ThreeDSMethodURL.
+func (r *CardVerificationResponse) MethodForm() (string, error) {
+	if r.ThreeDSMethodURL == "" {
+		return "", nil
+	}
+	var page strings.Builder
+	if err := methodForm.Execute(&page, r); err != nil {
+		return "", err
+	}
+	return page.String(), nil
+}
@@ -0,0 +1,175 @@
+package jsoncore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// =====================================================
+//
+// 3D SECURE CALLBACKS
+//
+// CardVerificationHandler receives what the ACS posts to
+// AuthorizationSuccessURL and AuthorizationFailedURL. Register the
+// MerchantData with Expect before sending the cardholder to the ACS,
+// mount the handler on both URLs and it calls OnResult with the
+// CardVerificationData for CreateVirtualCard or CardPayment.
+// The result comes from the fields the cardholder's browser posted,
+// it is not verified until valitor accepts the CardVerificationData.
+//
+// =====================================================
+
+// Errors returned by CardVerificationHandler.
+var (
+	ErrMissingMerchantData  = errors.New("Merchant Data missing")
+	ErrUnknownMerchantData  = errors.New("Merchant Data is not a pending card verification")
+	ErrHandlerNotConfigured = errors.New("CardVerificationHandler needs Pending and OnResult, create it with NewCardVerificationHandler")
+)
+
+// maxCallbackSize is more than any ACS posts back.
+const maxCallbackSize = 64 << 10
+
+// PendingVerifications ...
+// Remembers the MerchantData of card verifications waiting for the ACS.
+// Use a shared implementation when the callback can reach another instance of your application.
+type PendingVerifications interface {
+	// Add remembers merchantData until expires.
+	Add(ctx context.Context, merchantData string, expires time.Time) error
+	// Take forgets merchantData and reports whether it was pending and not expired.
+	Take(ctx context.Context, merchantData string) (bool, error)
+}
+
+// NewMemoryPendingVerifications keeps the pending verifications in memory.
+func NewMemoryPendingVerifications() PendingVerifications {
+	return &memoryPendingVerifications{pending: make(map[string]time.Time)}
+}
+
+type memoryPendingVerifications struct {
+	mu      sync.Mutex
+	pending map[string]time.Time
+}
+
+func (m *memoryPendingVerifications) Add(ctx context.Context, merchantData string, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for key, at := range m.pending {
+		if now.After(at) {
+			delete(m.pending, key)
+		}
+	}
+	m.pending[merchantData] = expires
+	return nil
+}
+
+func (m *memoryPendingVerifications) Take(ctx context.Context, merchantData string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expires, ok := m.pending[merchantData]
+	delete(m.pending, merchantData)
+	return ok && time.Now().Before(expires), nil
+}
+
+// CardVerificationResult ...
+// The outcome of 3-D Secure for one pending verification.
+type CardVerificationResult struct {
+	MerchantData string
+	// Data is empty unless Authenticated is true.
+	Data CardVerificationData
+	// Authenticated is true when the ACS posted CardVerificationData, it is read from the form
+	// and not verified. Only trust it once CreateVirtualCard or CardPayment with Data succeeds.
+	Authenticated bool
+}
+
+// CardVerificationHandler ...
+// An http.Handler for AuthorizationSuccessURL and AuthorizationFailedURL.
+// Create it with NewCardVerificationHandler, it is safe for concurrent use.
+type CardVerificationHandler struct {
+	// Pending holds the verifications registered with Expect.
+	Pending PendingVerifications
+	// Timeout is how long a verification waits for its callback, 15 minutes by default.
+	Timeout time.Duration
+	// OnResult is called for every pending verification, authenticated or not, and writes the response,
+	// usually a redirect back to the checkout.
+	OnResult func(w http.ResponseWriter, r *http.Request, result CardVerificationResult)
+	// OnError is called when the callback can not be used, the default answers 400 Bad Request.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// NewCardVerificationHandler creates a handler that keeps the pending verifications in memory.
+func NewCardVerificationHandler(onResult func(w http.ResponseWriter, r *http.Request, result CardVerificationResult)) *CardVerificationHandler {
+	return &CardVerificationHandler{
+		Pending:  NewMemoryPendingVerifications(),
+		Timeout:  15 * time.Minute,
+		OnResult: onResult,
+	}
+}
+
+// Expect registers the MerchantData of a card verification before the cardholder is sent to the ACS.
+// Every MerchantData is accepted once, use a new one for every verification.
+// It fails with ErrHandlerNotConfigured if Pending or OnResult is nil.
+func (h *CardVerificationHandler) Expect(ctx context.Context, cardVerification *CardVerification) error {
+	if h.Pending == nil || h.OnResult == nil {
+		return ErrHandlerNotConfigured
+	}
+	if cardVerification.MerchantData == "" {
+		return ErrMissingMerchantData
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Minute
+	}
+	return h.Pending.Add(ctx, cardVerification.MerchantData, time.Now().Add(timeout))
+}
+
+// ServeHTTP reads the form posted by the ACS and calls OnResult.
+func (h *CardVerificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Pending == nil || h.OnResult == nil {
+		http.Error(w, ErrHandlerNotConfigured.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxCallbackSize)
+	if err := r.ParseForm(); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	callback, err := ParseCardVerificationCallback(r.PostForm)
+	if callback.MerchantData == "" {
+		h.fail(w, r, ErrMissingMerchantData)
+		return
+	}
+	pending, takeErr := h.Pending.Take(r.Context(), callback.MerchantData)
+	if takeErr != nil {
+		h.fail(w, r, takeErr)
+		return
+	}
+	if !pending {
+		h.fail(w, r, ErrUnknownMerchantData)
+		return
+	}
+
+	result := CardVerificationResult{MerchantData: callback.MerchantData}
+	if err == nil {
+		result.Data = callback.Data
+		result.Authenticated = true
+	}
+	h.OnResult(w, r, result)
+}
+
+func (h *CardVerificationHandler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if h.OnError != nil {
+		h.OnError(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -1,51 +1,249 @@
 package jsoncore
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opensourcez/go-valitor/card"
 )
 
 // =====================================================
 //
 // 3D SECURE VALIDATION
 //
+// 1. VerifyCardUsing3DSecure sends the card to /CardVerification,
+//    with BrowserInfo for EMV 3-D Secure (3DS2).
+// 2. Branch on response.Flow(). FlowFrictionless is authenticated
+//    already, take response.VerificationData() and go to step 4.
+//    FlowChallenge needs the cardholder, send them to the ACS of the
+//    card issuer with RedirectForm.
+// 3. The ACS posts the result to AuthorizationSuccessURL or
+//    AuthorizationFailedURL, read it with ParseCardVerificationCallback.
+// 4. Pass the CardVerificationData to CreateVirtualCard or CardPayment.
+//
 // Documentation: https://uat.valitorpay.com/index.html#operation/CardVerification
 //
 // =====================================================
 
+// Errors returned before a card verification is sent or when a callback can not be used.
+var (
+	ErrMissingCallbackURL      = errors.New("Authorization Success URL and Authorization Failed URL missing")
+	ErrMissingVerificationData = errors.New("Card Verification Data missing")
+)
+
+// CardVerificationData ...
+// The result of 3-D Secure, pass it to CreateVirtualCard or CardPayment.
+// For 3DS2 PayerAuthenticationResponse is the transStatus and
+// CardholderAuthenticationVerificationData the authentication value.
 type CardVerificationData struct {
 	VerifyingEnrollmentResponse              string `json:"verifyingEnrollmentResponse"`
 	PayerAuthenticationResponse              string `json:"payerAuthenticationResponse"`
 	CardholderAuthenticationVerificationData string `json:"cardholderAuthenticationVerificationData"`
+	// The fields below are only sent for 3DS2.
+	ECI                  string `json:"eci,omitempty"`
+	DSTransID            string `json:"dsTransId,omitempty"`
+	ThreeDSServerTransID string `json:"threeDSServerTransId,omitempty"`
+	MessageVersion       string `json:"messageVersion,omitempty"`
 }
 
 // CardVerification ...
+// AgreementNumber and TerminalID are taken from the settings when they are empty.
+// Amount is in minor units. BrowserInfo asks for EMV 3-D Secure (3DS2), without it 3DS1 is used.
 // Documentation: https://uat.valitorpay.com/index.html#operation/CardVerification
 type CardVerification struct {
-	AgreementNumber         string `json:"agreementNumber"`
-	TerminalID              string `json:"terminalId"`
-	CardType                string `json:"cardType"`
-	CardNumber              string `json:"cardNumber"`
-	ExpirationMonth         int    `json:"expirationMonth"`
-	ExpirationYear          int    `json:"expirationYear"`
-	CardholderDeviceType    string `json:"cardholderDeviceType"`
-	Amount                  int    `json:"amount"`
-	Currency                string `json:"currency"`
-	AuthorizationSuccessURL string `json:"authorizationSuccessUrl"`
-	AuthorizationFailedURL  string `json:"authorizationFailedUrl"`
-	MerchantData            string `json:"merchantData"`
-}
-
-// TODO: Finish this and get some info.
+	AgreementNumber         string               `json:"agreementNumber"`
+	TerminalID              string               `json:"terminalId"`
+	CardType                string               `json:"cardType"`
+	CardNumber              string               `json:"cardNumber"`
+	ExpirationMonth         int                  `json:"expirationMonth"`
+	ExpirationYear          int                  `json:"expirationYear"`
+	CardholderDeviceType    CardholderDeviceType `json:"cardholderDeviceType"`
+	Amount                  int                  `json:"amount"`
+	Currency                string               `json:"currency"`
+	AuthorizationSuccessURL string               `json:"authorizationSuccessUrl"`
+	AuthorizationFailedURL  string               `json:"authorizationFailedUrl"`
+	MerchantData            string               `json:"merchantData"`
+
+	// BrowserInfo is only sent for 3DS2.
+	BrowserInfo *BrowserInfo `json:"browserInfo,omitempty"`
+}
+
+// VerificationField ...
+// A form field the cardholder's browser posts to the ACS, for example MD or PaReq.
+type VerificationField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CardVerificationResponse ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/CardVerification
+type CardVerificationResponse struct {
+	SystemError error
+	// PostURL is the ACS of the card issuer.
+	PostURL            string              `json:"postUrl"`
+	VerificationFields []VerificationField `json:"verificationFields"`
+	// CardVerificationRawResponse is an HTML page that posts VerificationFields to PostURL by itself.
+	CardVerificationRawResponse string `json:"cardVerificationRawResponse"`
+	IsSuccess                   bool   `json:"isSuccess"`
+	Code                        string `json:"responseCode"`
+	Description                 string `json:"responseDescription"`
+
+	// The fields below are only set for 3DS2.
+	MessageVersion       string      `json:"messageVersion"`
+	TransStatus          TransStatus `json:"transStatus"`
+	ThreeDSServerTransID string      `json:"threeDSServerTransId"`
+	DSTransID            string      `json:"dsTransId"`
+	ACSTransID           string      `json:"acsTransId"`
+	ECI                  string      `json:"eci"`
+	AuthenticationValue  string      `json:"authenticationValue"`
+	// ThreeDSMethodURL is where the issuer collects browser data, see MethodForm.
+	ThreeDSMethodURL  string `json:"threeDSMethodUrl"`
+	ThreeDSMethodData string `json:"threeDSMethodData"`
+}
+
+// Field returns the value of a verification field, names are matched without case.
+func (r *CardVerificationResponse) Field(name string) string {
+	for _, field := range r.VerificationFields {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// MD returns the merchant data field the ACS posts back with the result.
+func (r *CardVerificationResponse) MD() string {
+	return r.Field("MD")
+}
+
+// PaReq returns the payer authentication request for the ACS.
+func (r *CardVerificationResponse) PaReq() string {
+	return r.Field("PaReq")
+}
+
+var redirectForm = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="POST" action="{{.PostURL}}">
+{{range .VerificationFields}}<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+{{end}}<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+// RedirectForm returns an HTML page that sends the cardholder to the ACS.
+// CardVerificationRawResponse is used when valitor sent one.
+func (r *CardVerificationResponse) RedirectForm() (string, error) {
+	if r.CardVerificationRawResponse != "" {
+		return r.CardVerificationRawResponse, nil
+	}
+	if r.PostURL == "" {
+		return "", errors.New("Post URL missing")
+	}
+	var page strings.Builder
+	if err := redirectForm.Execute(&page, r); err != nil {
+		return "", err
+	}
+	return page.String(), nil
+}
+
 // VerifyCardUsing3DSecure ...
+// Starts 3-D Secure for a card, send the cardholder to the ACS with response.RedirectForm().
+// cardVerification is not modified.
 // Documentation: https://uat.valitorpay.com/index.html#operation/CardVerification
-func (cs CompanyService) VerifyCardUsing3DSecure(cardVerification *CardVerification) (card Card, err error) {
+func (cs *CompanyService) VerifyCardUsing3DSecure(ctx context.Context, cardVerification *CardVerification) (response CardVerificationResponse) {
 
-	verificationAsJSON, jsonError := json.Marshal(cardVerification)
-	if jsonError != nil {
-		err = jsonError
+	if err := card.ValidateNumber(cardVerification.CardNumber); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := card.ValidateExpiry(cardVerification.ExpirationMonth, cardVerification.ExpirationYear, time.Now()); err != nil {
+		response.SystemError = err
+		return
+	}
+	if cardVerification.AuthorizationSuccessURL == "" || cardVerification.AuthorizationFailedURL == "" {
+		response.SystemError = ErrMissingCallbackURL
+		return
+	}
+	if err := cardVerification.CardholderDeviceType.Validate(); err != nil {
+		response.SystemError = err
 		return
 	}
-	log.Println(verificationAsJSON)
+	if cardVerification.BrowserInfo != nil {
+		if err := cardVerification.BrowserInfo.Validate(); err != nil {
+			response.SystemError = err
+			return
+		}
+	}
+
+	settings := cs.snapshot()
+	Request := *cardVerification
+	if Request.AgreementNumber == "" {
+		Request.AgreementNumber = settings.AgreementNumber
+	}
+	if Request.TerminalID == "" {
+		Request.TerminalID = settings.TerminalID
+	}
+	if Request.CardType == "" {
+		Request.CardType = string(card.DetectBrand(Request.CardNumber))
+	}
+	Request.CardNumber = card.Normalize(Request.CardNumber)
+	Request.ExpirationYear = card.NormalizeYear(Request.ExpirationYear)
+
+	if err := cs.send(ctx, settings, "/CardVerification", &Request, &response); err != nil {
+		response.SystemError = err
+		response.Code, response.Description = errorCodeAndDescription(err)
+	}
+	return
+}
+
+// CardVerificationCallback ...
+// What the ACS posts to AuthorizationSuccessURL or AuthorizationFailedURL.
+type CardVerificationCallback struct {
+	// MerchantData is the MerchantData sent with the CardVerification.
+	MerchantData string
+	Data         CardVerificationData
+}
+
+// callbackFields maps the posted form fields to the callback, the 3-D Secure 1
+// names MD, PaRes and cavv and the 3DS2 names transStatus and authenticationValue
+// are accepted as well.
+var callbackFields = []struct {
+	names []string
+	field func(c *CardVerificationCallback) *string
+}{
+	{[]string{"merchantData", "MD"}, func(c *CardVerificationCallback) *string { return &c.MerchantData }},
+	{[]string{"verifyingEnrollmentResponse", "VERes"}, func(c *CardVerificationCallback) *string { return &c.Data.VerifyingEnrollmentResponse }},
+	{[]string{"payerAuthenticationResponse", "PaRes", "transStatus"}, func(c *CardVerificationCallback) *string { return &c.Data.PayerAuthenticationResponse }},
+	{[]string{"cardholderAuthenticationVerificationData", "cavv", "authenticationValue"}, func(c *CardVerificationCallback) *string { return &c.Data.CardholderAuthenticationVerificationData }},
+	{[]string{"eci"}, func(c *CardVerificationCallback) *string { return &c.Data.ECI }},
+	{[]string{"dsTransId"}, func(c *CardVerificationCallback) *string { return &c.Data.DSTransID }},
+	{[]string{"threeDSServerTransId"}, func(c *CardVerificationCallback) *string { return &c.Data.ThreeDSServerTransID }},
+	{[]string{"messageVersion"}, func(c *CardVerificationCallback) *string { return &c.Data.MessageVersion }},
+}
+
+// ParseCardVerificationCallback reads the form posted by the ACS.
+// It returns ErrMissingVerificationData, together with the MerchantData, when
+// the cardholder was not authenticated.
+func ParseCardVerificationCallback(form url.Values) (callback CardVerificationCallback, err error) {
+	for _, f := range callbackFields {
+		for _, name := range f.names {
+			if value := form.Get(name); value != "" {
+				*f.field(&callback) = value
+				break
+			}
+		}
+	}
+	if callback.Data.PayerAuthenticationResponse == "" && callback.Data.CardholderAuthenticationVerificationData == "" {
+		err = ErrMissingVerificationData
+	}
+	if status := form.Get("transStatus"); status != "" && !TransStatus(status).Authenticated() {
+		err = ErrMissingVerificationData
+	}
 	return
 }
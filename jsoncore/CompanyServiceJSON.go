@@ -1,15 +1,22 @@
 package jsoncore
 
 import (
+	"context"
 	"encoding/json"
-	"log"
-	"math/rand"
+	"log/slog"
+	"net/http"
 	"strconv"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/opensourcez/go-valitor/card"
 	"github.com/opensourcez/go-valitor/helpers"
+	"github.com/opensourcez/go-valitor/metrics"
+	"github.com/opensourcez/go-valitor/money"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Card struct {
@@ -24,33 +31,125 @@ type Card struct {
 }
 
 func (c *Card) GetLastFour() string {
-	return c.Number[len(c.Number)-5:]
+	return card.LastFour(c.Number)
 }
 
+// NormalizedNumber returns the card number without spaces and dashes, this is what is sent to valitor.
+func (c *Card) NormalizedNumber() string {
+	return card.Normalize(c.Number)
+}
+
+// Brand returns the card brand detected from the card number.
+func (c *Card) Brand() card.Brand {
+	return card.DetectBrand(c.Number)
+}
+
+// Expiry returns the expiration date of the card, ExpYear can have two or four digits.
+func (c *Card) Expiry() card.Expiry {
+	return card.NewExpiry(c.ExpMonth, c.ExpYear)
+}
+
+// LogValue implements slog.LogValuer, the number is masked and the CVC is left out.
+func (c Card) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("number", helpers.MaskPAN(c.Number)),
+		slog.String("virtualNumber", c.VirtualNumber),
+		slog.String("expiry", c.Expiry().MMYY()),
+	)
+}
+
+// ExpirationYear returns the expiration year with four digits, ValitorPay expects 2022 and not 22.
+func (c *Card) ExpirationYear() int {
+	return c.Expiry().FourDigitYear()
+}
+
+// ValidateNumber checks the card number length and Luhn checksum.
+func (c *Card) ValidateNumber() error {
+	return card.ValidateNumber(c.Number)
+}
+
+// ValidateExpiration checks that the expiration date is valid and not in the past.
+func (c *Card) ValidateExpiration() error {
+	return c.Expiry().Validate(time.Now())
+}
+
+// Validate checks the card number and expiration date.
+func (c *Card) Validate() error {
+	if err := c.ValidateNumber(); err != nil {
+		return err
+	}
+	return c.ValidateExpiration()
+}
+
+// CompanyService ...
+// A CompanyService is safe for concurrent use by many goroutines. The exported
+// fields must not be changed after the first call, use SetSettings to rotate credentials.
 type CompanyService struct {
-	Settings *Settings
-	Mux      sync.RWMutex
+	settings atomic.Pointer[Settings]
+	// HTTPClient is used for every request made by the service.
+	// If it is nil helpers.DefaultClient is used.
+	HTTPClient *http.Client
+	// Logger receives one record per call to valitor, request and response bodies
+	// are logged at slog.LevelDebug. If it is nil helpers.Logger() is used.
+	// Card data and credentials are always redacted.
+	Logger *slog.Logger
+	// TracerProvider and Propagator are used to trace every call to valitor.
+	// If they are nil the global ones from otel are used.
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+	// Metrics records every call to valitor, nothing is recorded if it is nil.
+	Metrics *metrics.Collector
+	// Retry decides which failed calls are sent again, nothing is retried if it is nil.
+	Retry *helpers.RetryPolicy
+	// CircuitBreaker fails calls at once while valitor is down, every call is sent if it is nil.
+	CircuitBreaker *helpers.CircuitBreaker
 }
 type Settings struct {
 	AgreementNumber string
 	TerminalID      string
 	URL             string
+	// APIKey and APIVersion are used when Credentials is nil.
+	// If APIVersion is empty DefaultAPIVersion is used.
+	APIKey     string
+	APIVersion string
+	// Credentials takes precedence over APIKey and APIVersion
+	// and is asked for credentials before every request.
+	Credentials CredentialProvider
+}
+
+// Settings returns a copy of the current settings.
+func (cs *CompanyService) Settings() Settings {
+	return *cs.snapshot()
+}
+
+// SetSettings replaces the settings in one step, for example to rotate the API key.
+// Calls that have already started finish with the old settings.
+func (cs *CompanyService) SetSettings(settings Settings) {
+	cs.settings.Store(&settings)
+}
+
+// snapshot returns the settings for a single call, they must not be modified.
+func (cs *CompanyService) snapshot() *Settings {
+	if settings := cs.settings.Load(); settings != nil {
+		return settings
+	}
+	return &Settings{}
 }
 
 // VirtualCardRequest ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/CreateVirtualCard
 type VirtualCardRequest struct {
-	CardNumber                string                `json:"cardNumber"`
-	ExpirationMonth           int                   `json:"expirationMonth"`
-	ExpirationYear            int                   `json:"expirationYear"`
-	Cvc                       string                `json:"cvc"`
-	AgreementNumber           string                `json:"agreementNumber"`
-	TerminalID                string                `json:"terminalId"`
-	SubsequentTransactionType string                `json:"subsequentTransactionType"`
-	TransactionType           string                `json:"transactionType"`
-	TransactionLifecycleID    string                `json:"TransactionLifecycleID,omitempty"`
-	CardVerificationData      *CardVerificationData `json:"CardVerificationData,omitempty"`
-	Currency                  string                `json:"currency,omitempty"`
+	CardNumber                string                    `json:"cardNumber"`
+	ExpirationMonth           int                       `json:"expirationMonth"`
+	ExpirationYear            int                       `json:"expirationYear"`
+	Cvc                       string                    `json:"cvc"`
+	AgreementNumber           string                    `json:"agreementNumber"`
+	TerminalID                string                    `json:"terminalId"`
+	SubsequentTransactionType SubsequentTransactionType `json:"subsequentTransactionType"`
+	TransactionType           TransactionType           `json:"transactionType"`
+	TransactionLifecycleID    string                    `json:"TransactionLifecycleID,omitempty"`
+	CardVerificationData      *CardVerificationData     `json:"CardVerificationData,omitempty"`
+	Currency                  string                    `json:"currency,omitempty"`
 }
 
 // VirtualCardResponse ...
@@ -69,18 +168,35 @@ type VirtualCardResponse struct {
 // CreateAVirtualCard ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/CreateVirtualCard
 func (cs *CompanyService) CreateVirtualCard(
+	ctx context.Context,
 	card *Card,
 	cardVerificationData *CardVerificationData,
-	subsequentTransactionType, transactionType, transactionLifecycleID string,
+	subsequentTransactionType SubsequentTransactionType,
+	transactionType TransactionType,
+	transactionLifecycleID string,
 ) (response VirtualCardResponse) {
 
+	if err := card.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := subsequentTransactionType.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := transactionType.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	settings := cs.snapshot()
 	Request := &VirtualCardRequest{
-		CardNumber:      card.Number,
+		CardNumber:      card.NormalizedNumber(),
 		ExpirationMonth: card.ExpMonth,
-		ExpirationYear:  card.ExpYear,
+		ExpirationYear:  card.ExpirationYear(),
 		Cvc:             card.CVC,
-		// AgreementNumber:           cs.Settings.AgreementNumber,
-		// TerminalID:                cs.Settings.TerminalID,
+		// AgreementNumber:           settings.AgreementNumber,
+		// TerminalID:                settings.TerminalID,
 		SubsequentTransactionType: subsequentTransactionType,
 		TransactionType:           transactionType,
 		CardVerificationData:      cardVerificationData,
@@ -89,55 +205,54 @@ func (cs *CompanyService) CreateVirtualCard(
 
 	// if there is no TransactionLifecycleID we try to make a new one.
 	if transactionLifecycleID == "" {
-		rand.Seed(time.Now().UnixNano())
-		id, err := uuid.NewUUID()
-		if err == nil {
-			Request.TransactionLifecycleID = id.String()
-			response.TransactionLifecycleID = id.String()
+		if id, err := uuid.NewUUID(); err == nil {
+			transactionLifecycleID = id.String()
 		}
 	}
+	Request.TransactionLifecycleID = transactionLifecycleID
+	response.TransactionLifecycleID = transactionLifecycleID
 
-	requestAsJSON, err := json.Marshal(Request)
-
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-
-	resp, code, err := helpers.SendJSON(requestAsJSON, "POST", cs.Settings.URL+"/VirtualCard/CreateVirtualCard")
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if code != 200 {
-		response.Code = strconv.Itoa(code)
-		response.IsSuccess = false
-		response.Description = getDescriptionForNone200Code(code)
-	}
-	log.Println(resp)
-	if err := json.Unmarshal(resp, &response); err != nil {
+	if err := cs.send(ctx, settings, "/VirtualCard/CreateVirtualCard", Request, &response); err != nil {
 		response.SystemError = err
-		return
+		response.Code, response.Description = errorCodeAndDescription(err)
 	}
-
 	return
 }
 
-// CreateAVirtualCard ...
+// CardPaymentWithVerification ...
+// Posted to CreateVirtualCard like CreateVirtualCard, but logged, traced and retried as a payment:
+// it is not sent again after a timeout and fails with helpers.ErrOutcomeUnknown instead.
 // Documentation: https://uat.valitorpay.com/index.html#operation/CardPaymentWithVerification
 func (cs *CompanyService) CardPaymentWithVerification(
+	ctx context.Context,
 	card *Card,
 	cardVerificationData *CardVerificationData,
-	subsequentTransactionType, transactionType, transactionLifecycleID string,
+	subsequentTransactionType SubsequentTransactionType,
+	transactionType TransactionType,
+	transactionLifecycleID string,
 ) (response VirtualCardResponse) {
 
+	if err := card.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := subsequentTransactionType.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := transactionType.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	settings := cs.snapshot()
 	Request := &VirtualCardRequest{
-		CardNumber:      card.Number,
+		CardNumber:      card.NormalizedNumber(),
 		ExpirationMonth: card.ExpMonth,
-		ExpirationYear:  card.ExpYear,
+		ExpirationYear:  card.ExpirationYear(),
 		Cvc:             card.CVC,
-		// AgreementNumber:           cs.Settings.AgreementNumber,
-		// TerminalID:                cs.Settings.TerminalID,
+		// AgreementNumber:           settings.AgreementNumber,
+		// TerminalID:                settings.TerminalID,
 		SubsequentTransactionType: subsequentTransactionType,
 		TransactionType:           transactionType,
 		CardVerificationData:      cardVerificationData,
@@ -146,37 +261,17 @@ func (cs *CompanyService) CardPaymentWithVerification(
 
 	// if there is no TransactionLifecycleID we try to make a new one.
 	if transactionLifecycleID == "" {
-		rand.Seed(time.Now().UnixNano())
-		id, err := uuid.NewUUID()
-		if err == nil {
-			Request.TransactionLifecycleID = id.String()
-			response.TransactionLifecycleID = id.String()
+		if id, err := uuid.NewUUID(); err == nil {
+			transactionLifecycleID = id.String()
 		}
 	}
+	Request.TransactionLifecycleID = transactionLifecycleID
+	response.TransactionLifecycleID = transactionLifecycleID
 
-	requestAsJSON, err := json.Marshal(Request)
-
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-
-	resp, code, err := helpers.SendJSON(requestAsJSON, "POST", cs.Settings.URL+"/VirtualCard/CreateVirtualCard")
-	if err != nil {
+	if err := cs.sendAs(ctx, settings, "CardPaymentWithVerification", "/VirtualCard/CreateVirtualCard", Request, &response); err != nil {
 		response.SystemError = err
-		return
-	}
-	if code != 200 {
-		response.Code = strconv.Itoa(code)
-		response.IsSuccess = false
-		response.Description = getDescriptionForNone200Code(code)
-	}
-	log.Println(resp)
-	if err := json.Unmarshal(resp, &response); err != nil {
-		response.SystemError = err
-		return
+		response.Code, response.Description = errorCodeAndDescription(err)
 	}
-
 	return
 }
 
@@ -189,7 +284,7 @@ type VirtualCardExpirationUpdateRequest struct {
 	Cvc                  string                `json:"cvc"`
 	AgreementNumber      string                `json:"agreementNumber"`
 	TerminalID           string                `json:"terminalId"`
-	TransactionType      string                `json:"transactionType"`
+	TransactionType      TransactionType       `json:"transactionType"`
 	CardVerificationData *CardVerificationData `json:"CardVerificationData,omitempty"`
 }
 
@@ -207,50 +302,44 @@ type VirtualCardExpirationUpdateResponse struct {
 // UpdateAVirtualCardsExpirationDate ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/UpdateExpirationDate
 func (cs *CompanyService) UpdateExpirationDate(
+	ctx context.Context,
 	card *Card,
 	cardVerificationData *CardVerificationData,
-	transactionType string,
+	transactionType TransactionType,
 ) (response VirtualCardExpirationUpdateResponse) {
 
-	Request := &VirtualCardExpirationUpdateRequest{
-		VirtualCardNumber:    card.VirtualNumber,
-		ExpirationMonth:      card.ExpMonth,
-		ExpirationYear:       card.ExpYear,
-		Cvc:                  card.CVC,
-		AgreementNumber:      cs.Settings.AgreementNumber,
-		TerminalID:           cs.Settings.TerminalID,
-		TransactionType:      transactionType,
-		CardVerificationData: cardVerificationData,
-	}
-	requestAsJSON, err := json.Marshal(Request)
-
-	if err != nil {
+	if err := card.ValidateExpiration(); err != nil {
 		response.SystemError = err
 		return
 	}
-	resp, code, err := helpers.SendJSON(requestAsJSON, "POST", cs.Settings.URL+"/VirtualCard/UpdateExpirationDate")
-	if err != nil {
+	if err := transactionType.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
-	if code != 200 {
-		response.Code = strconv.Itoa(code)
-		response.IsSuccess = false
-		response.Description = getDescriptionForNone200Code(code)
+
+	settings := cs.snapshot()
+	Request := &VirtualCardExpirationUpdateRequest{
+		VirtualCardNumber:    card.VirtualNumber,
+		ExpirationMonth:      card.ExpMonth,
+		ExpirationYear:       card.ExpirationYear(),
+		Cvc:                  card.CVC,
+		AgreementNumber:      settings.AgreementNumber,
+		TerminalID:           settings.TerminalID,
+		TransactionType:      transactionType,
+		CardVerificationData: cardVerificationData,
 	}
-	if err := json.Unmarshal(resp, &response); err != nil {
+	if err := cs.send(ctx, settings, "/VirtualCard/UpdateExpirationDate", Request, &response); err != nil {
 		response.SystemError = err
-		return
+		response.Code, response.Description = errorCodeAndDescription(err)
 	}
-
 	return
 }
 
 // CardPaymentRequest ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/CardPayment
 type CardPaymentRequest struct {
-	Operation                 string                     `json:"operation"`
-	TransactionType           string                     `json:"transactionType"`
+	Operation                 Operation                  `json:"operation"`
+	TransactionType           TransactionType            `json:"transactionType"`
 	Currency                  string                     `json:"currency"`
 	Amount                    int                        `json:"amount"`
 	TerminalID                string                     `json:"terminalId"`
@@ -283,11 +372,11 @@ type CardPaymentResponse struct {
 // CardPayment ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/CardPayment
 func (cs *CompanyService) CardPayment(
+	ctx context.Context,
 	card *Card,
-	operation string,
-	transactionType string,
-	currency string,
-	amount int,
+	operation Operation,
+	transactionType TransactionType,
+	amount money.Money,
 	referenceNumer string,
 	useAsFirstTransaction string,
 	subsequentTransactionData *SubsequentTransactionData,
@@ -295,16 +384,41 @@ func (cs *CompanyService) CardPayment(
 	dccData *DCCData,
 ) (response CardPaymentResponse) {
 
+	if err := card.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	if err := amount.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := operation.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := transactionType.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if subsequentTransactionData != nil {
+		if err := subsequentTransactionData.SubsequentTransactionType.Validate(); err != nil {
+			response.SystemError = err
+			return
+		}
+	}
+
+	settings := cs.snapshot()
 	Request := &CardPaymentRequest{
 		Operation:                 operation,
-		CardNumber:                card.Number,
+		CardNumber:                card.NormalizedNumber(),
 		ExpirationMonth:           card.ExpMonth,
-		ExpirationYear:            card.ExpYear,
+		ExpirationYear:            card.ExpirationYear(),
 		Cvc:                       card.CVC,
-		AgreementNumber:           cs.Settings.AgreementNumber,
-		TerminalID:                cs.Settings.TerminalID,
-		Amount:                    amount,
-		Currency:                  currency,
+		AgreementNumber:           settings.AgreementNumber,
+		TerminalID:                settings.TerminalID,
+		Amount:                    int(amount.MinorUnits()),
+		Currency:                  amount.Currency.String(),
 		ReferenceNumber:           referenceNumer,
 		UseAsFirstTransaction:     useAsFirstTransaction,
 		TransactionType:           transactionType,
@@ -312,47 +426,24 @@ func (cs *CompanyService) CardPayment(
 		SubsequentTransactionData: subsequentTransactionData,
 		DCCData:                   dccData,
 	}
-	requestAsJSON, err := json.Marshal(Request)
-
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	resp, code, err := helpers.SendJSON(requestAsJSON, "POST", cs.Settings.URL+"/Payment/CardPayment")
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	// code 400 will send back a json object describing the error.
-	// it is sufficient to forward this object.
-	if code == 400 {
-		response.Code = strconv.Itoa(400)
-		response.IsSuccess = false
-		response.Description = string(resp)
-	} else if code != 200 {
-		response.Code = strconv.Itoa(code)
-		response.IsSuccess = false
-		response.Description = getDescriptionForNone200Code(code)
-	}
-	if err := json.Unmarshal(resp, &response); err != nil {
+	if err := cs.send(ctx, settings, "/Payment/CardPayment", Request, &response); err != nil {
 		response.SystemError = err
-		return
+		response.Code, response.Description = errorCodeAndDescription(err)
 	}
-
 	return
 }
 
 // VirtualCardPaymentRequest ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/VirtualCardPayment
 type VirtualCardPaymentRequest struct {
-	Operation         string `json:"operation"`
-	Currency          string `json:"currency"`
-	Amount            int    `json:"amount"`
-	TerminalID        string `json:"terminalId"`
-	AgreementNumber   string `json:"agreementNumber"`
-	VirtualCardNumber string `json:"virtualCardNumber"`
-	ReferenceNumber   string `json:"referenceNumber"`
-	InitiationReason  string `json:"initiationReason,omitempty"`
+	Operation         Operation        `json:"operation"`
+	Currency          string           `json:"currency"`
+	Amount            int              `json:"amount"`
+	TerminalID        string           `json:"terminalId"`
+	AgreementNumber   string           `json:"agreementNumber"`
+	VirtualCardNumber string           `json:"virtualCardNumber"`
+	ReferenceNumber   string           `json:"referenceNumber"`
+	InitiationReason  InitiationReason `json:"initiationReason,omitempty"`
 }
 
 // VirtualCardPaymentResponse ...
@@ -370,46 +461,41 @@ type VirtualCardPaymentResponse struct {
 }
 
 // VirtualCardPayment ...
+// Charges a virtual card, the amount is authorized and captured at once, see VirtualCardAuthorization.
 // Documentation: https://uat.valitorpay.com/index.html#operation/VirtualCardPayment
 func (cs *CompanyService) VirtualCardPayment(
+	ctx context.Context,
 	card *Card,
-	initialReason string,
-	currency string,
-	amount int,
+	initialReason InitiationReason,
+	amount money.Money,
 	referenceNumer string,
 
 ) (response VirtualCardPaymentResponse) {
 
-	Request := &VirtualCardPaymentRequest{
-		VirtualCardNumber: card.VirtualNumber,
-		AgreementNumber:   cs.Settings.AgreementNumber,
-		TerminalID:        cs.Settings.TerminalID,
-		Amount:            amount,
-		Currency:          currency,
-		ReferenceNumber:   referenceNumer,
-		InitiationReason:  initialReason,
-	}
-	requestAsJSON, err := json.Marshal(Request)
-
-	if err != nil {
+	if err := amount.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
-	resp, code, err := helpers.SendJSON(requestAsJSON, "POST", cs.Settings.URL+"/Payment/VirtualCardPayment")
-	if err != nil {
+	if err := initialReason.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
-	if code != 200 {
-		response.Code = strconv.Itoa(code)
-		response.IsSuccess = false
-		response.Description = getDescriptionForNone200Code(code)
+
+	settings := cs.snapshot()
+	Request := &VirtualCardPaymentRequest{
+		Operation:         OperationSale,
+		VirtualCardNumber: card.VirtualNumber,
+		AgreementNumber:   settings.AgreementNumber,
+		TerminalID:        settings.TerminalID,
+		Amount:            int(amount.MinorUnits()),
+		Currency:          amount.Currency.String(),
+		ReferenceNumber:   referenceNumer,
+		InitiationReason:  initialReason,
 	}
-	if err := json.Unmarshal(resp, &response); err != nil {
+	if err := cs.send(ctx, settings, "/Payment/VirtualCardPayment", Request, &response); err != nil {
 		response.SystemError = err
-		return
+		response.Code, response.Description = errorCodeAndDescription(err)
 	}
-
 	return
 }
 
@@ -443,60 +529,68 @@ type DCCOfferResponse struct {
 // DCCOffer ...
 // Documentation: https://uat.valitorpay.com/index.html#operation/VirtualCardPayment
 func (cs *CompanyService) Dcc(
+	ctx context.Context,
 	card *Card,
-	currency string,
-	amount int,
+	amount money.Money,
 ) (response DCCOfferResponse) {
 
-	Request := &DCCOfferRequest{
-		CardNumber:      card.Number,
-		AgreementNumber: cs.Settings.AgreementNumber,
-		TerminalID:      cs.Settings.TerminalID,
-		Amount:          amount,
-		Currency:        currency,
-	}
-	requestAsJSON, err := json.Marshal(Request)
-
-	if err != nil {
+	if err := card.ValidateNumber(); err != nil {
 		response.SystemError = err
 		return
 	}
-	resp, code, err := helpers.SendJSON(requestAsJSON, "POST", cs.Settings.URL+"/Dcc")
-	if err != nil {
+
+	if err := amount.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
-	if code != 200 {
-		response.Code = strconv.Itoa(code)
-		response.IsSuccess = false
-		response.Description = getDescriptionForNone200Code(code)
+
+	settings := cs.snapshot()
+	Request := &DCCOfferRequest{
+		CardNumber:      card.NormalizedNumber(),
+		AgreementNumber: settings.AgreementNumber,
+		TerminalID:      settings.TerminalID,
+		Amount:          int(amount.MinorUnits()),
+		Currency:        amount.Currency.String(),
 	}
-	if err := json.Unmarshal(resp, &response); err != nil {
+	if err := cs.send(ctx, settings, "/Dcc", Request, &response); err != nil {
 		response.SystemError = err
-		return
+		response.Code, response.Description = errorCodeAndDescription(err)
 	}
-
 	return
 }
 
 // =====================================================
 //
-// GENERAL STRUCTS USED FOR REQUESTS AND RESPONSES
+// # GENERAL STRUCTS USED FOR REQUESTS AND RESPONSES
 //
 // =====================================================
 type SubsequentTransactionData struct {
-	IsStoredCredential        string `json:"isStoredCredential"`
-	TransactionLifecycleID    string `json:"transactionLifecycleId,omitempty"`
-	SubsequentTransactionType string `json:"subsequentTransactionType"`
+	IsStoredCredential        string                    `json:"isStoredCredential"`
+	TransactionLifecycleID    string                    `json:"transactionLifecycleId,omitempty"`
+	SubsequentTransactionType SubsequentTransactionType `json:"subsequentTransactionType"`
 }
 
+// DCCData ...
+// Amounts are in minor units, fill it from a DCCOfferResponse with NewDCCData.
 type DCCData struct {
-	originalTransAmount          int64   `json:"originalTransAmount"`
-	originalTransCurrency        string  `json:"originalTransCurrency"`
-	dccCardholderBillingFee      int64   `json:"dccCardholderBillingFee"`
-	dccExchangeRate              float64 `json:"dccExchangeRate"`
-	dccOfferCreationDate         string  `json:"dccOfferCreationDate"`
-	dccInformationEncryptedValue string  `json:"dccInformationEncryptedValue"`
+	OriginalTransAmount          int64   `json:"originalTransAmount"`
+	OriginalTransCurrency        string  `json:"originalTransCurrency"`
+	DccCardholderBillingFee      int64   `json:"dccCardholderBillingFee"`
+	DccExchangeRate              float64 `json:"dccExchangeRate"`
+	DccOfferCreationDate         string  `json:"dccOfferCreationDate"`
+	DccInformationEncryptedValue string  `json:"dccInformationEncryptedValue"`
+}
+
+// NewDCCData returns the DCCData for a CardPayment that accepts the offer.
+func NewDCCData(offer *DCCOfferResponse) *DCCData {
+	return &DCCData{
+		OriginalTransAmount:          int64(offer.Amount),
+		OriginalTransCurrency:        offer.Currency,
+		DccCardholderBillingFee:      int64(offer.DccCardholderBillingFee),
+		DccExchangeRate:              offer.ExchangeRate,
+		DccOfferCreationDate:         offer.ResponseTimestamp,
+		DccInformationEncryptedValue: offer.DccInformationEncryptedValue,
+	}
 }
 
 // =====================================================
@@ -505,12 +599,94 @@ type DCCData struct {
 //
 // =====================================================
 
+// send marshals the request, posts it to the given path and unmarshals the answer into response.
+// Answers other than 200 are returned as a *ProblemDetails or *HTTPError.
+func (cs *CompanyService) send(ctx context.Context, settings *Settings, path string, request interface{}, response interface{}) error {
+	return cs.sendAs(ctx, settings, operationName(path), path, request, response)
+}
+
+// sendAs is send for methods that share a path with another operation,
+// the call is logged, traced and measured as operation instead of the name of the path.
+func (cs *CompanyService) sendAs(ctx context.Context, settings *Settings, operation string, path string, request interface{}, response interface{}) error {
+	requestAsJSON, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := cs.startSpan(ctx, settings, operation, request)
+	logger := helpers.ServiceLogger(cs.Logger).With("operation", operation)
+	idempotency := idempotency(operation, request)
+	restore := helpers.Snapshot(response)
+	start := time.Now()
+	var resp []byte
+	var code int
+	failure := cs.Retry.Do(ctx, idempotency, func() (failure helpers.Failure) {
+		restore()
+		// Without credentials nothing is sent, the call is neither retried nor counted against the endpoint.
+		creds, credentialsErr := settings.credentials(ctx)
+		if credentialsErr != nil {
+			resp, code, err = nil, 0, credentialsErr
+			return helpers.FailureNone
+		}
+		release, breakerErr := cs.CircuitBreaker.Acquire(ctx, settings.URL+path)
+		if breakerErr != nil {
+			resp, code, err = nil, 0, breakerErr
+			return helpers.FailureNone
+		}
+		defer func() { release(helpers.EndpointOutcome(ctx, failure)) }()
+
+		logger.DebugContext(ctx, "valitor request", "url", settings.URL+path, "body", requestAsJSON)
+		resp, code, err = cs.sendJSON(ctx, settings, creds, requestAsJSON, path)
+		if err != nil {
+			return helpers.ClassifyError(err)
+		}
+		logger.DebugContext(ctx, "valitor response", "status", code, "body", resp)
+		if code != http.StatusOK {
+			err = newHTTPError(code, resp)
+		} else {
+			err = json.Unmarshal(resp, response)
+		}
+		return classify(code, response)
+	}, func(attempt int, failure helpers.Failure, wait time.Duration) {
+		helpers.LogRetry(ctx, logger, attempt, failure, wait, code, err)
+		cs.Metrics.ObserveRetry(metrics.CoreJSON, operation)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("valitor.attempt", attempt), attribute.String("valitor.failure", failure.String())))
+	})
+	err = helpers.OutcomeUnknown(err, idempotency, failure)
+	duration := time.Since(start)
+	details := errorDetails(response)
+	helpers.LogResult(ctx, logger, duration, code, err, details, logAttrs(err, requestAsJSON, resp)...)
+	helpers.Observe(cs.Metrics, metrics.CoreJSON, operation, duration, code, err, details)
+	helpers.EndSpan(span, code, err, details)
+	return err
+}
+
+// sendJSON posts the request to the given path with creds attached.
+func (cs *CompanyService) sendJSON(ctx context.Context, settings *Settings, creds Credentials, requestAsJSON []byte, path string) ([]byte, int, error) {
+	header := http.Header{}
+	header.Set("Authorization", "APIKey "+creds.APIKey)
+	header.Set("valitor-api-version", creds.APIVersion)
+	cs.injectTraceContext(ctx, header)
+	resp, code, err := helpers.SendJSON(ctx, cs.HTTPClient, requestAsJSON, "POST", settings.URL+path, header)
+	if code == http.StatusUnauthorized {
+		settings.invalidateCredentials()
+	}
+	return resp, code, err
+}
+
 // For when we get a code other then 200 from valitor.
 func getDescriptionForNone200Code(code int) string {
 	switch code {
+	case 400:
+		return "Bad request from valitor, code: 400"
 	case 401:
-		return "Unauthorized..??"
+		return "Unauthorized, check the API key, code: 401"
+	case 403:
+		return "Forbidden, check the agreement number and terminal id, code: 403"
 	default:
+		if text := http.StatusText(code); text != "" {
+			return text + " from valitor, code: " + strconv.Itoa(code)
+		}
 		return "Unknown error from valitor, code: " + strconv.Itoa(code)
 	}
 
@@ -0,0 +1,191 @@
+package jsoncore
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =====================================================
+//
+// API CREDENTIALS
+//
+// Documentation: https://uat.valitorpay.com/index.html#section/Authentication
+//
+// =====================================================
+
+// DefaultAPIVersion is sent in the valitor-api-version header when
+// no other version has been configured.
+const DefaultAPIVersion = "2.0"
+
+// ErrMissingAPIKey is returned when no API key could be found for a request.
+var ErrMissingAPIKey = errors.New("API key missing")
+
+// Credentials ...
+// The API key and API version sent with every ValitorPay request.
+type Credentials struct {
+	APIKey     string
+	APIVersion string
+}
+
+// CredentialProvider ...
+// Credentials are fetched from the provider before every request,
+// so a provider can rotate keys without the service being restarted.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentials ...
+// Always returns the same API key and version.
+type StaticCredentials Credentials
+
+func (s StaticCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// EnvCredentials ...
+// Reads the API key (and optionally the version) from environment variables
+// every time credentials are requested.
+type EnvCredentials struct {
+	APIKeyVariable     string
+	APIVersionVariable string
+}
+
+func (e EnvCredentials) Credentials(ctx context.Context) (creds Credentials, err error) {
+	creds.APIKey = os.Getenv(e.APIKeyVariable)
+	if e.APIVersionVariable != "" {
+		creds.APIVersion = os.Getenv(e.APIVersionVariable)
+	}
+	return
+}
+
+// FileCredentials ...
+// Reads the API key from a file every time credentials are requested.
+// Leading and trailing whitespace is removed from the key.
+// This works well with mounted secrets that are rotated in place.
+type FileCredentials struct {
+	Path       string
+	APIVersion string
+}
+
+func (f FileCredentials) Credentials(ctx context.Context) (creds Credentials, err error) {
+	key, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return
+	}
+	creds.APIKey = strings.TrimSpace(string(key))
+	creds.APIVersion = f.APIVersion
+	return
+}
+
+// RefreshingCredentials ...
+// Caches the credentials returned by Fetch for TTL and fetches them again
+// once they expire. If TTL is zero the credentials are cached until Invalidate is called.
+// Only one Fetch runs at a time, concurrent requests wait for it until their ctx is done.
+// Fetch is not canceled when the request that started it gives up, give it its own timeout.
+// RefreshingCredentials must not be copied after first use.
+type RefreshingCredentials struct {
+	Fetch func(ctx context.Context) (Credentials, error)
+	TTL   time.Duration
+
+	mux      sync.Mutex
+	current  Credentials
+	expires  time.Time
+	fetched  bool
+	inFlight *fetchCall
+}
+
+// fetchCall is a Fetch shared by every request that needs new credentials.
+type fetchCall struct {
+	done  chan struct{}
+	creds Credentials
+	err   error
+}
+
+func (r *RefreshingCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	r.mux.Lock()
+	if r.fetched && (r.TTL == 0 || time.Now().Before(r.expires)) {
+		creds := r.current
+		r.mux.Unlock()
+		return creds, nil
+	}
+	call := r.inFlight
+	if call == nil {
+		call = &fetchCall{done: make(chan struct{})}
+		r.inFlight = call
+		go r.fetch(context.WithoutCancel(ctx), call)
+	}
+	r.mux.Unlock()
+
+	select {
+	case <-call.done:
+		return call.creds, call.err
+	case <-ctx.Done():
+		return Credentials{}, ctx.Err()
+	}
+}
+
+func (r *RefreshingCredentials) fetch(ctx context.Context, call *fetchCall) {
+	call.creds, call.err = r.Fetch(ctx)
+
+	r.mux.Lock()
+	if call.err == nil {
+		r.current = call.creds
+		r.expires = time.Now().Add(r.TTL)
+		r.fetched = true
+	}
+	r.inFlight = nil
+	r.mux.Unlock()
+	close(call.done)
+}
+
+// Invalidate forces the next call to Credentials to fetch new credentials.
+// The service calls it when valitor answers 401 Unauthorized.
+func (r *RefreshingCredentials) Invalidate() {
+	r.mux.Lock()
+	r.fetched = false
+	r.mux.Unlock()
+}
+
+// invalidator is implemented by providers that cache credentials, like RefreshingCredentials.
+type invalidator interface {
+	Invalidate()
+}
+
+// invalidateCredentials drops cached credentials after valitor rejected them.
+func (s *Settings) invalidateCredentials() {
+	if i, ok := s.Credentials.(invalidator); ok {
+		i.Invalidate()
+	}
+}
+
+// credentials resolves the credentials for a single request.
+func (s *Settings) credentials(ctx context.Context) (creds Credentials, err error) {
+	if s.Credentials != nil {
+		creds, err = s.Credentials.Credentials(ctx)
+		if err != nil {
+			return
+		}
+	} else {
+		creds = Credentials{
+			APIKey:     s.APIKey,
+			APIVersion: s.APIVersion,
+		}
+	}
+
+	if creds.APIKey == "" {
+		err = ErrMissingAPIKey
+		return
+	}
+	if creds.APIVersion == "" {
+		creds.APIVersion = s.APIVersion
+	}
+	if creds.APIVersion == "" {
+		creds.APIVersion = DefaultAPIVersion
+	}
+	return
+}
@@ -0,0 +1,152 @@
+package jsoncore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// =====================================================
+//
+// ENUMS
+//
+// The ValitorPay fields that only take a fixed set of values.
+// Use the constants, a value that is not in the set fails with
+// ErrInvalidValue before anything is sent to valitor.
+//
+// Documentation: https://uat.valitorpay.com/index.html
+//
+// =====================================================
+
+// ErrInvalidValue is wrapped around every error returned by the Validate methods below.
+var ErrInvalidValue = errors.New("Value not supported by ValitorPay")
+
+func invalidValue(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%w: %s missing", ErrInvalidValue, field)
+	}
+	return fmt.Errorf("%w: %s %q", ErrInvalidValue, field, value)
+}
+
+// Operation is the operation of a CardPayment or VirtualCardPayment.
+type Operation string
+
+const (
+	// OperationSale authorizes and captures the amount.
+	OperationSale Operation = "Sale"
+	// OperationAuthorization only reserves the amount, see Capture and Reversal.
+	OperationAuthorization Operation = "Authorization"
+)
+
+// Validate returns an error wrapping ErrInvalidValue if o is not a known operation.
+func (o Operation) Validate() error {
+	switch o {
+	case OperationSale, OperationAuthorization:
+		return nil
+	}
+	return invalidValue("operation", string(o))
+}
+
+// TransactionType tells valitor how the card details were entered.
+type TransactionType string
+
+const (
+	// TransactionTypeECommerce is an online payment without a CVC, for example with a stored card.
+	TransactionTypeECommerce TransactionType = "ECommerce"
+	// TransactionTypeECommerceWithCvc is an online payment where the cardholder entered the CVC.
+	TransactionTypeECommerceWithCvc TransactionType = "ECommerceWithCvc"
+	// TransactionTypeMOTO is a mail or telephone order.
+	TransactionTypeMOTO TransactionType = "MOTO"
+)
+
+// Validate returns an error wrapping ErrInvalidValue if t is not a known transaction type.
+func (t TransactionType) Validate() error {
+	switch t {
+	case TransactionTypeECommerce, TransactionTypeECommerceWithCvc, TransactionTypeMOTO:
+		return nil
+	}
+	return invalidValue("transactionType", string(t))
+}
+
+// SubsequentTransactionType tells valitor who starts the payments made with a stored card.
+type SubsequentTransactionType string
+
+const (
+	// SubsequentTransactionCardholderInitiatedCredentialOnFile payments are started by the cardholder, like a one click checkout.
+	SubsequentTransactionCardholderInitiatedCredentialOnFile SubsequentTransactionType = "CardholderInitiatedCredentialOnFile"
+	// SubsequentTransactionMerchantInitiatedCredentialOnFile payments are started by the merchant at no fixed schedule.
+	SubsequentTransactionMerchantInitiatedCredentialOnFile SubsequentTransactionType = "MerchantInitiatedCredentialOnFile"
+	// SubsequentTransactionMerchantInitiatedRecurring payments are started by the merchant on a fixed schedule, like a subscription.
+	SubsequentTransactionMerchantInitiatedRecurring SubsequentTransactionType = "MerchantInitiatedRecurring"
+	// SubsequentTransactionMerchantInitiatedInstallment payments split one purchase into a known number of payments.
+	SubsequentTransactionMerchantInitiatedInstallment SubsequentTransactionType = "MerchantInitiatedInstallment"
+)
+
+// Validate returns an error wrapping ErrInvalidValue if t is not a known subsequent transaction type.
+func (t SubsequentTransactionType) Validate() error {
+	switch t {
+	case SubsequentTransactionCardholderInitiatedCredentialOnFile,
+		SubsequentTransactionMerchantInitiatedCredentialOnFile,
+		SubsequentTransactionMerchantInitiatedRecurring,
+		SubsequentTransactionMerchantInitiatedInstallment:
+		return nil
+	}
+	return invalidValue("subsequentTransactionType", string(t))
+}
+
+// InitiationReason is why the merchant started a VirtualCardPayment, empty when the cardholder started it.
+type InitiationReason string
+
+const (
+	// InitiationReasonUnscheduled is a payment at no fixed schedule, for example topping up a stored balance.
+	InitiationReasonUnscheduled InitiationReason = "Unscheduled"
+	// InitiationReasonRecurring is one of a series of payments on a fixed schedule, like a subscription.
+	InitiationReasonRecurring InitiationReason = "Recurring"
+	// InitiationReasonInstallment is one of a known number of payments for a single purchase.
+	InitiationReasonInstallment InitiationReason = "Installment"
+	// InitiationReasonIncremental adds to an amount that was already authorized, for example a hotel stay that was extended.
+	InitiationReasonIncremental InitiationReason = "Incremental"
+	// InitiationReasonResubmission sends a payment again that was declined, for example for insufficient funds.
+	InitiationReasonResubmission InitiationReason = "Resubmission"
+	// InitiationReasonDelayedCharges charges for something found after the purchase, like damage to a rental car.
+	InitiationReasonDelayedCharges InitiationReason = "DelayedCharges"
+	// InitiationReasonReauthorization authorizes again after the first authorization expired, for example a split shipment.
+	InitiationReasonReauthorization InitiationReason = "Reauthorization"
+	// InitiationReasonNoShow charges the penalty when the cardholder did not show up for a reservation.
+	InitiationReasonNoShow InitiationReason = "NoShow"
+)
+
+// Validate returns an error wrapping ErrInvalidValue if r is neither empty nor a known initiation reason.
+func (r InitiationReason) Validate() error {
+	switch r {
+	case "",
+		InitiationReasonUnscheduled,
+		InitiationReasonRecurring,
+		InitiationReasonInstallment,
+		InitiationReasonIncremental,
+		InitiationReasonResubmission,
+		InitiationReasonDelayedCharges,
+		InitiationReasonReauthorization,
+		InitiationReasonNoShow:
+		return nil
+	}
+	return invalidValue("initiationReason", string(r))
+}
+
+// CardholderDeviceType is the device the cardholder uses for 3-D Secure.
+type CardholderDeviceType string
+
+const (
+	// CardholderDeviceTypeWWW is a web browser.
+	CardholderDeviceTypeWWW CardholderDeviceType = "WWW"
+	// CardholderDeviceTypeWAP is a mobile browser without full HTML support.
+	CardholderDeviceTypeWAP CardholderDeviceType = "WAP"
+)
+
+// Validate returns an error wrapping ErrInvalidValue if t is neither empty nor a known device type.
+func (t CardholderDeviceType) Validate() error {
+	switch t {
+	case "", CardholderDeviceTypeWWW, CardholderDeviceTypeWAP:
+		return nil
+	}
+	return invalidValue("cardholderDeviceType", string(t))
+}
@@ -0,0 +1,290 @@
+package jsoncore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opensourcez/go-valitor/helpers"
+)
+
+// =====================================================
+//
+// VALITORPAY ERRORS
+//
+// Non 200 answers are placed in SystemError as a *ProblemDetails
+// when valitor sends a problem details body, otherwise as an *HTTPError.
+// Answers with isSuccess false are turned into a *ResponseError by Err().
+//
+// Documentation: https://uat.valitorpay.com/index.html#section/Response-codes
+//
+// =====================================================
+
+// maxErrorBody is how much of an unexpected body is kept in HTTPError.
+const maxErrorBody = 512
+
+// Sentinel errors for HTTP status codes, use them with errors.Is.
+var (
+	ErrBadRequest   = errors.New("Bad request")
+	ErrUnauthorized = errors.New("Unauthorized, check the API key")
+	ErrForbidden    = errors.New("Forbidden, check the agreement number and terminal id")
+)
+
+// Sentinel errors for response codes, use them with errors.Is.
+var (
+	ErrDeclined          = errors.New("Card declined")
+	ErrReferral          = errors.New("Refer to card issuer")
+	ErrInsufficientFunds = errors.New("Insufficient funds")
+	ErrExpiredCard       = errors.New("Card expired")
+	ErrInvalidCardNumber = errors.New("Invalid card number")
+	ErrInvalidCVC        = errors.New("Invalid CVC")
+	ErrExceedsLimit      = errors.New("Exceeds withdrawal limit")
+	ErrInvalidMerchant   = errors.New("Invalid merchant")
+	ErrInvalidAmount     = errors.New("Invalid amount")
+	ErrInvalidRequest    = errors.New("Invalid transaction")
+	ErrIssuerUnavailable = errors.New("Issuer or switch unavailable")
+)
+
+// responseCodes maps responseCode to a sentinel error and category.
+// Codes not listed here are reported with helpers.CategoryUnknown.
+var responseCodes = map[string]helpers.CatalogEntry{
+	"01": {Err: ErrReferral, Category: helpers.CategoryCardholder},
+	"02": {Err: ErrReferral, Category: helpers.CategoryCardholder},
+	"03": {Err: ErrInvalidMerchant, Category: helpers.CategoryMerchantConfig},
+	"04": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"05": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"07": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"12": {Err: ErrInvalidRequest, Category: helpers.CategoryMerchantConfig},
+	"13": {Err: ErrInvalidAmount, Category: helpers.CategoryMerchantConfig},
+	"14": {Err: ErrInvalidCardNumber, Category: helpers.CategoryCardholder},
+	"19": {Err: ErrIssuerUnavailable, Category: helpers.CategoryRetryable},
+	"30": {Err: ErrInvalidRequest, Category: helpers.CategoryMerchantConfig},
+	"41": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"43": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"51": {Err: ErrInsufficientFunds, Category: helpers.CategoryCardholder},
+	"54": {Err: ErrExpiredCard, Category: helpers.CategoryCardholder},
+	"57": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"58": {Err: ErrInvalidMerchant, Category: helpers.CategoryMerchantConfig},
+	"59": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"61": {Err: ErrExceedsLimit, Category: helpers.CategoryCardholder},
+	"62": {Err: ErrDeclined, Category: helpers.CategoryCardholder},
+	"65": {Err: ErrExceedsLimit, Category: helpers.CategoryCardholder},
+	"82": {Err: ErrInvalidCVC, Category: helpers.CategoryCardholder},
+	"91": {Err: ErrIssuerUnavailable, Category: helpers.CategoryRetryable},
+	"96": {Err: ErrIssuerUnavailable, Category: helpers.CategoryRetryable},
+	"N7": {Err: ErrInvalidCVC, Category: helpers.CategoryCardholder},
+}
+
+// CategoryForCode returns the category of a ValitorPay responseCode.
+func CategoryForCode(code string) helpers.Category {
+	return responseCodes[strings.ToUpper(code)].Category
+}
+
+// ProblemDetails ...
+// The body valitor sends back with HTTP 400 validation errors.
+// Errors maps each invalid field to its messages.
+type ProblemDetails struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail"`
+	Instance string              `json:"instance"`
+	TraceID  string              `json:"traceId"`
+	Errors   map[string][]string `json:"errors"`
+}
+
+func (p *ProblemDetails) Error() string {
+	msg := p.Title
+	if msg == "" {
+		msg = getDescriptionForNone200Code(p.Status)
+	}
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	for _, field := range p.Fields() {
+		msg += "; " + field + ": " + strings.Join(p.Errors[field], ", ")
+	}
+	if p.TraceID != "" {
+		msg += " (trace id: " + p.TraceID + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel error for the HTTP status.
+func (p *ProblemDetails) Unwrap() error {
+	return statusError(p.Status)
+}
+
+// Fields returns the names of the invalid fields in sorted order.
+func (p *ProblemDetails) Fields() []string {
+	fields := make([]string, 0, len(p.Errors))
+	for field := range p.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// HTTPError ...
+// Valitor answered with an HTTP status other than 200 and no problem details.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return getDescriptionForNone200Code(e.StatusCode)
+}
+
+// Unwrap returns the sentinel error for the HTTP status.
+func (e *HTTPError) Unwrap() error {
+	return statusError(e.StatusCode)
+}
+
+// ResponseError ...
+// Valitor answered with isSuccess false.
+type ResponseError struct {
+	Operation   string
+	Code        string
+	Description string
+}
+
+func (e *ResponseError) Error() string {
+	msg := e.Operation + ": response code " + e.Code
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel error for the code, or nil if the code is unknown.
+func (e *ResponseError) Unwrap() error {
+	return responseCodes[strings.ToUpper(e.Code)].Err
+}
+
+// Category returns who can fix the problem.
+func (e *ResponseError) Category() helpers.Category {
+	return CategoryForCode(e.Code)
+}
+
+// errorDetails returns the responseCode in response for logs, spans and metrics, or nil if there is none.
+func errorDetails(response interface{}) *helpers.ErrorDetails {
+	r, ok := response.(interface{ Err() error })
+	if !ok {
+		return nil
+	}
+	var responseError *ResponseError
+	if !errors.As(r.Err(), &responseError) {
+		return nil
+	}
+	return &helpers.ErrorDetails{
+		Code:     responseError.Code,
+		Message:  responseError.Description,
+		Category: responseError.Category(),
+	}
+}
+
+func statusError(statusCode int) error {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	default:
+		return nil
+	}
+}
+
+// newHTTPError turns a non 200 answer into a *ProblemDetails or *HTTPError.
+func newHTTPError(statusCode int, body []byte) error {
+	problem := &ProblemDetails{}
+	if err := json.Unmarshal(body, problem); err == nil && (problem.Title != "" || len(problem.Errors) > 0) {
+		if problem.Status == 0 {
+			problem.Status = statusCode
+		}
+		return problem
+	}
+
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return &HTTPError{StatusCode: statusCode, Body: string(body)}
+}
+
+// errorCodeAndDescription fills Code and Description on responses when the request failed.
+func errorCodeAndDescription(err error) (code string, description string) {
+	var problem *ProblemDetails
+	var httpError *HTTPError
+	switch {
+	case errors.As(err, &problem):
+		return strconv.Itoa(problem.Status), problem.Error()
+	case errors.As(err, &httpError):
+		return strconv.Itoa(httpError.StatusCode), httpError.Error()
+	}
+	return "", err.Error()
+}
+
+// responseError is shared by the Err() methods on the responses.
+func responseError(operation string, systemError error, isSuccess bool, code, description string) error {
+	if systemError != nil {
+		return systemError
+	}
+	if isSuccess {
+		return nil
+	}
+	return &ResponseError{
+		Operation:   operation,
+		Code:        code,
+		Description: description,
+	}
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r VirtualCardResponse) Err() error {
+	return responseError("CreateVirtualCard", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r VirtualCardExpirationUpdateResponse) Err() error {
+	return responseError("UpdateExpirationDate", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r CardPaymentResponse) Err() error {
+	return responseError("CardPayment", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r VirtualCardPaymentResponse) Err() error {
+	return responseError("VirtualCardPayment", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r DCCOfferResponse) Err() error {
+	return responseError("Dcc", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r CardVerificationResponse) Err() error {
+	return responseError("CardVerification", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r CaptureResponse) Err() error {
+	return responseError("Capture", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r ReversalResponse) Err() error {
+	return responseError("Reversal", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
+
+// Err returns SystemError, or a *ResponseError if isSuccess is false.
+func (r RefundResponse) Err() error {
+	return responseError("Refund", r.SystemError, r.IsSuccess, r.Code, r.Description)
+}
@@ -0,0 +1,50 @@
+package jsoncore
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path"
+)
+
+// operationName turns a path like /Payment/CardPayment into CardPayment.
+func operationName(p string) string {
+	return path.Base(p)
+}
+
+// lifecycleID finds the transactionLifecycleId in a request or response body.
+// encoding/json matches keys without case, so TransactionLifecycleID is found as well.
+func lifecycleID(bodies ...[]byte) string {
+	for _, body := range bodies {
+		var ids struct {
+			TransactionLifecycleID    string `json:"transactionLifecycleId"`
+			SubsequentTransactionData struct {
+				TransactionLifecycleID string `json:"transactionLifecycleId"`
+			} `json:"subsequentTransactionData"`
+		}
+		if json.Unmarshal(body, &ids) != nil {
+			continue
+		}
+		if ids.TransactionLifecycleID != "" {
+			return ids.TransactionLifecycleID
+		}
+		if ids.SubsequentTransactionData.TransactionLifecycleID != "" {
+			return ids.SubsequentTransactionData.TransactionLifecycleID
+		}
+	}
+	return ""
+}
+
+// logAttrs returns the attributes only a JSON call has for helpers.LogResult,
+// the transactionLifecycleId and the traceId from the problem details valitor support asks for.
+func logAttrs(err error, request, response []byte) []slog.Attr {
+	var attrs []slog.Attr
+	if id := lifecycleID(response, request); id != "" {
+		attrs = append(attrs, slog.String("transaction_lifecycle_id", id))
+	}
+	var problem *ProblemDetails
+	if errors.As(err, &problem) && problem.TraceID != "" {
+		attrs = append(attrs, slog.String("trace_id", problem.TraceID))
+	}
+	return attrs
+}
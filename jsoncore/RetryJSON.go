@@ -0,0 +1,79 @@
+package jsoncore
+
+import (
+	"errors"
+
+	"github.com/opensourcez/go-valitor/helpers"
+)
+
+// safeOperations only create or update stored card data, ask for an offer or start a new
+// card verification, sending them twice does no harm. CardPaymentWithVerification is posted
+// to CreateVirtualCard as well but is not on the list, callers treat it as a payment.
+var safeOperations = map[string]bool{
+	"CardVerification":     true,
+	"CreateVirtualCard":    true,
+	"UpdateExpirationDate": true,
+	"Dcc":                  true,
+}
+
+// keyedRequest is implemented by payments that can carry an idempotency key.
+type keyedRequest interface {
+	idempotencyKey() string
+}
+
+func (r *CardPaymentRequest) idempotencyKey() string {
+	if r.SubsequentTransactionData != nil && r.SubsequentTransactionData.TransactionLifecycleID != "" {
+		return r.SubsequentTransactionData.TransactionLifecycleID
+	}
+	return r.ReferenceNumber
+}
+
+func (r *VirtualCardPaymentRequest) idempotencyKey() string {
+	return r.ReferenceNumber
+}
+
+// Capturing or reversing the same transaction twice is refused by valitor.
+func (r *CaptureRequest) idempotencyKey() string {
+	if r.TransactionLifecycleID != "" {
+		return r.TransactionLifecycleID
+	}
+	return r.TransactionID
+}
+
+func (r *ReversalRequest) idempotencyKey() string {
+	if r.TransactionLifecycleID != "" {
+		return r.TransactionLifecycleID
+	}
+	return r.TransactionID
+}
+
+// A transaction can be refunded more than once, only the ReferenceNumber tells refunds apart.
+func (r *RefundRequest) idempotencyKey() string {
+	return r.ReferenceNumber
+}
+
+// idempotency returns helpers.IdempotencySafe for safeOperations, helpers.IdempotencyKeyed
+// for payments with a ReferenceNumber or TransactionLifecycleID and helpers.IdempotencyNone otherwise.
+func idempotency(operation string, request interface{}) helpers.Idempotency {
+	if safeOperations[operation] {
+		return helpers.IdempotencySafe
+	}
+	if r, ok := request.(keyedRequest); ok && r.idempotencyKey() != "" {
+		return helpers.IdempotencyKeyed
+	}
+	return helpers.IdempotencyNone
+}
+
+// classify tells the retry policy how an answer from valitor failed.
+func classify(statusCode int, response interface{}) helpers.Failure {
+	if statusCode != 200 {
+		return helpers.ClassifyStatus(statusCode)
+	}
+	if r, ok := response.(interface{ Err() error }); ok {
+		var responseError *ResponseError
+		if errors.As(r.Err(), &responseError) && responseError.Category().Retryable() {
+			return helpers.FailureRejected
+		}
+	}
+	return helpers.FailureNone
+}
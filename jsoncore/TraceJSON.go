@@ -0,0 +1,69 @@
+package jsoncore
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opensourcez/go-valitor/helpers"
+	"github.com/opensourcez/go-valitor/money"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer, the spans are started by helpers.StartSpan.
+const instrumentationName = "github.com/opensourcez/go-valitor/jsoncore"
+
+// amountRequest is implemented by requests that carry an amount or a currency.
+type amountRequest interface {
+	amount() money.Money
+}
+
+func (r *VirtualCardRequest) amount() money.Money {
+	return money.Money{Currency: money.Currency(r.Currency)}
+}
+
+func (r *CardPaymentRequest) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+func (r *VirtualCardPaymentRequest) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+func (r *CardVerification) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+func (r *CaptureRequest) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+func (r *ReversalRequest) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+func (r *RefundRequest) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+func (r *DCCOfferRequest) amount() money.Money {
+	return money.Money{Amount: int64(r.Amount), Currency: money.Currency(r.Currency)}
+}
+
+// startSpan starts the client span for operation.
+func (cs *CompanyService) startSpan(ctx context.Context, settings *Settings, operation string, request interface{}) (context.Context, trace.Span) {
+	var amount money.Money
+	if r, ok := request.(amountRequest); ok {
+		amount = r.amount()
+	}
+	return helpers.StartSpan(ctx, helpers.Tracer(cs.TracerProvider, instrumentationName), operation, amount,
+		attribute.String("valitor.agreement_number", settings.AgreementNumber),
+		attribute.String("valitor.terminal_id", settings.TerminalID),
+	)
+}
+
+// injectTraceContext adds the traceparent header for the span in ctx.
+func (cs *CompanyService) injectTraceContext(ctx context.Context, header http.Header) {
+	helpers.Propagator(cs.Propagator).Inject(ctx, propagation.HeaderCarrier(header))
+}
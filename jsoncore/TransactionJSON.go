@@ -0,0 +1,251 @@
+package jsoncore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opensourcez/go-valitor/money"
+)
+
+// =====================================================
+//
+// CAPTURE, REVERSAL AND REFUND
+//
+// VirtualCardAuthorization (or CardPayment with the Authorization
+// operation) reserves the amount. Capture takes the money, Reversal
+// cancels the reservation and Refund pays captured money back.
+// All three find the original payment by its TransactionID or
+// TransactionLifecycleID, at least one of them is required.
+//
+// =====================================================
+
+// ErrMissingTransaction is returned when neither TransactionID nor TransactionLifecycleID is given.
+var ErrMissingTransaction = errors.New("Transaction ID or Transaction Lifecycle ID missing")
+
+// VirtualCardAuthorization ...
+// The same as VirtualCardPayment, but the amount is only reserved until Capture or Reversal.
+// Documentation: https://uat.valitorpay.com/index.html#operation/VirtualCardPayment
+func (cs *CompanyService) VirtualCardAuthorization(
+	ctx context.Context,
+	card *Card,
+	initialReason InitiationReason,
+	amount money.Money,
+	referenceNumer string,
+) (response VirtualCardPaymentResponse) {
+
+	if err := amount.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+	if err := initialReason.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	settings := cs.snapshot()
+	Request := &VirtualCardPaymentRequest{
+		Operation:         OperationAuthorization,
+		VirtualCardNumber: card.VirtualNumber,
+		AgreementNumber:   settings.AgreementNumber,
+		TerminalID:        settings.TerminalID,
+		Amount:            int(amount.MinorUnits()),
+		Currency:          amount.Currency.String(),
+		ReferenceNumber:   referenceNumer,
+		InitiationReason:  initialReason,
+	}
+	if err := cs.sendAs(ctx, settings, "VirtualCardAuthorization", "/Payment/VirtualCardPayment", Request, &response); err != nil {
+		response.SystemError = err
+		response.Code, response.Description = errorCodeAndDescription(err)
+	}
+	return
+}
+
+// CaptureRequest ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/Capture
+type CaptureRequest struct {
+	AgreementNumber        string `json:"agreementNumber"`
+	TerminalID             string `json:"terminalId"`
+	TransactionID          string `json:"transactionID,omitempty"`
+	TransactionLifecycleID string `json:"transactionLifecycleId,omitempty"`
+	Amount                 int    `json:"amount"`
+	Currency               string `json:"currency"`
+	ReferenceNumber        string `json:"referenceNumber"`
+}
+
+// CaptureResponse ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/Capture
+type CaptureResponse struct {
+	SystemError               error
+	ReferenceNumber           string `json:"referenceNumber"`
+	TransactionID             string `json:"transactionID"`
+	AuthorizationCode         string `json:"authorizationCode"`
+	TransactionLifecycleID    string `json:"transactionLifecycleId"`
+	AuthorizationResponseTime string `json:"authorizationResponseTime"`
+	IsSuccess                 bool   `json:"isSuccess"`
+	Code                      string `json:"responseCode"`
+	Description               string `json:"responseDescription"`
+}
+
+// Capture ...
+// Captures an authorization, amount can be less than what was authorized.
+// Documentation: https://uat.valitorpay.com/index.html#operation/Capture
+func (cs *CompanyService) Capture(
+	ctx context.Context,
+	transactionID string,
+	transactionLifecycleID string,
+	amount money.Money,
+	referenceNumber string,
+) (response CaptureResponse) {
+
+	if transactionID == "" && transactionLifecycleID == "" {
+		response.SystemError = ErrMissingTransaction
+		return
+	}
+	if err := amount.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	settings := cs.snapshot()
+	Request := &CaptureRequest{
+		AgreementNumber:        settings.AgreementNumber,
+		TerminalID:             settings.TerminalID,
+		TransactionID:          transactionID,
+		TransactionLifecycleID: transactionLifecycleID,
+		Amount:                 int(amount.MinorUnits()),
+		Currency:               amount.Currency.String(),
+		ReferenceNumber:        referenceNumber,
+	}
+	if err := cs.send(ctx, settings, "/Payment/Capture", Request, &response); err != nil {
+		response.SystemError = err
+		response.Code, response.Description = errorCodeAndDescription(err)
+	}
+	return
+}
+
+// ReversalRequest ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/Reversal
+type ReversalRequest struct {
+	AgreementNumber        string `json:"agreementNumber"`
+	TerminalID             string `json:"terminalId"`
+	TransactionID          string `json:"transactionID,omitempty"`
+	TransactionLifecycleID string `json:"transactionLifecycleId,omitempty"`
+	Amount                 int    `json:"amount"`
+	Currency               string `json:"currency"`
+	ReferenceNumber        string `json:"referenceNumber"`
+}
+
+// ReversalResponse ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/Reversal
+type ReversalResponse struct {
+	SystemError               error
+	ReferenceNumber           string `json:"referenceNumber"`
+	TransactionID             string `json:"transactionID"`
+	AuthorizationCode         string `json:"authorizationCode"`
+	TransactionLifecycleID    string `json:"transactionLifecycleId"`
+	AuthorizationResponseTime string `json:"authorizationResponseTime"`
+	IsSuccess                 bool   `json:"isSuccess"`
+	Code                      string `json:"responseCode"`
+	Description               string `json:"responseDescription"`
+}
+
+// Reversal ...
+// Cancels an authorization or a payment that has not been settled, amount is the original amount.
+// Documentation: https://uat.valitorpay.com/index.html#operation/Reversal
+func (cs *CompanyService) Reversal(
+	ctx context.Context,
+	transactionID string,
+	transactionLifecycleID string,
+	amount money.Money,
+	referenceNumber string,
+) (response ReversalResponse) {
+
+	if transactionID == "" && transactionLifecycleID == "" {
+		response.SystemError = ErrMissingTransaction
+		return
+	}
+	if err := amount.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	settings := cs.snapshot()
+	Request := &ReversalRequest{
+		AgreementNumber:        settings.AgreementNumber,
+		TerminalID:             settings.TerminalID,
+		TransactionID:          transactionID,
+		TransactionLifecycleID: transactionLifecycleID,
+		Amount:                 int(amount.MinorUnits()),
+		Currency:               amount.Currency.String(),
+		ReferenceNumber:        referenceNumber,
+	}
+	if err := cs.send(ctx, settings, "/Payment/Reversal", Request, &response); err != nil {
+		response.SystemError = err
+		response.Code, response.Description = errorCodeAndDescription(err)
+	}
+	return
+}
+
+// RefundRequest ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/Refund
+type RefundRequest struct {
+	AgreementNumber        string `json:"agreementNumber"`
+	TerminalID             string `json:"terminalId"`
+	TransactionID          string `json:"transactionID,omitempty"`
+	TransactionLifecycleID string `json:"transactionLifecycleId,omitempty"`
+	Amount                 int    `json:"amount"`
+	Currency               string `json:"currency"`
+	ReferenceNumber        string `json:"referenceNumber"`
+}
+
+// RefundResponse ...
+// Documentation: https://uat.valitorpay.com/index.html#operation/Refund
+type RefundResponse struct {
+	SystemError               error
+	ReferenceNumber           string `json:"referenceNumber"`
+	TransactionID             string `json:"transactionID"`
+	AuthorizationCode         string `json:"authorizationCode"`
+	TransactionLifecycleID    string `json:"transactionLifecycleId"`
+	AuthorizationResponseTime string `json:"authorizationResponseTime"`
+	IsSuccess                 bool   `json:"isSuccess"`
+	Code                      string `json:"responseCode"`
+	Description               string `json:"responseDescription"`
+}
+
+// Refund ...
+// Pays captured money back to the card, amount can be less than what was captured.
+// Give every refund its own referenceNumber, otherwise a refund is not retried.
+// Documentation: https://uat.valitorpay.com/index.html#operation/Refund
+func (cs *CompanyService) Refund(
+	ctx context.Context,
+	transactionID string,
+	transactionLifecycleID string,
+	amount money.Money,
+	referenceNumber string,
+) (response RefundResponse) {
+
+	if transactionID == "" && transactionLifecycleID == "" {
+		response.SystemError = ErrMissingTransaction
+		return
+	}
+	if err := amount.Validate(); err != nil {
+		response.SystemError = err
+		return
+	}
+
+	settings := cs.snapshot()
+	Request := &RefundRequest{
+		AgreementNumber:        settings.AgreementNumber,
+		TerminalID:             settings.TerminalID,
+		TransactionID:          transactionID,
+		TransactionLifecycleID: transactionLifecycleID,
+		Amount:                 int(amount.MinorUnits()),
+		Currency:               amount.Currency.String(),
+		ReferenceNumber:        referenceNumber,
+	}
+	if err := cs.send(ctx, settings, "/Payment/Refund", Request, &response); err != nil {
+		response.SystemError = err
+		response.Code, response.Description = errorCodeAndDescription(err)
+	}
+	return
+}
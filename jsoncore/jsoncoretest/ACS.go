@@ -0,0 +1,209 @@
+package jsoncoretest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/opensourcez/go-valitor/jsoncore"
+)
+
+// =====================================================
+//
+// 3-D SECURE
+//
+// /CardVerification starts a session and sends the cardholder to
+// the fake ACS at /acs, which authenticates every cardholder and
+// posts the result to the AuthorizationSuccessURL. Tests that do
+// not want to follow the redirect can call Authenticate instead.
+// Requests with BrowserInfo get 3DS2 answers, frictionless unless
+// SetChallenge(true) was called.
+//
+// =====================================================
+
+type verification struct {
+	merchantData string
+	successURL   string
+	failedURL    string
+	// serverTransID and dsTransID are only set for 3DS2.
+	serverTransID string
+	dsTransID     string
+}
+
+// messageVersion is the 3DS2 version the server answers with.
+const messageVersion = "2.2.0"
+
+// SetChallenge makes 3DS2 verifications ask for a challenge instead of being frictionless.
+func (s *Server) SetChallenge(challenge bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challenge = challenge
+}
+
+func (s *Server) cardVerification(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.CardVerification
+	v := validator{}
+	if v.decode(body, &request) {
+		v.cardNumber(request.CardNumber)
+		v.require("currency", request.Currency != "")
+		v.require("amount", request.Amount > 0)
+		v.require("authorizationSuccessUrl", request.AuthorizationSuccessURL != "")
+		v.require("authorizationFailedUrl", request.AuthorizationFailedURL != "")
+		v.expiry(request.ExpirationMonth, request.ExpirationYear)
+		if request.BrowserInfo != nil {
+			v.require("browserAcceptHeader", request.BrowserInfo.AcceptHeader != "")
+			v.require("browserUserAgent", request.BrowserInfo.UserAgent != "")
+			v.require("browserLanguage", request.BrowserInfo.Language != "")
+		}
+	}
+	if len(v) > 0 {
+		return nil, v
+	}
+	if request.BrowserInfo != nil {
+		return s.cardVerification2(request), nil
+	}
+
+	md := fmt.Sprintf("md-%d", s.next())
+	s.mu.Lock()
+	s.verifications[md] = verification{
+		merchantData: request.MerchantData,
+		successURL:   request.AuthorizationSuccessURL,
+		failedURL:    request.AuthorizationFailedURL,
+	}
+	s.mu.Unlock()
+
+	response := approved()
+	response["postUrl"] = s.URL + "/acs"
+	response["verificationFields"] = []jsoncore.VerificationField{
+		{Name: "MD", Value: md},
+		{Name: "PaReq", Value: base64.StdEncoding.EncodeToString([]byte("pareq-" + md))},
+		{Name: "TermUrl", Value: request.AuthorizationSuccessURL},
+	}
+	return response, nil
+}
+
+func (s *Server) cardVerification2(request jsoncore.CardVerification) map[string]interface{} {
+	n := s.next()
+	session := verification{
+		merchantData:  request.MerchantData,
+		successURL:    request.AuthorizationSuccessURL,
+		failedURL:     request.AuthorizationFailedURL,
+		serverTransID: fmt.Sprintf("3dss-%08d", n),
+		dsTransID:     fmt.Sprintf("ds-%08d", n),
+	}
+	response := approved()
+	response["messageVersion"] = messageVersion
+	response["threeDSServerTransId"] = session.serverTransID
+	response["dsTransId"] = session.dsTransID
+	response["acsTransId"] = fmt.Sprintf("acs-%08d", n)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.challenge {
+		response["transStatus"] = jsoncore.TransStatusAuthenticated
+		response["eci"] = "05"
+		response["authenticationValue"] = s.issueCAVV()
+		return response
+	}
+
+	md := fmt.Sprintf("md-%d", n)
+	s.verifications[md] = session
+	response["transStatus"] = jsoncore.TransStatusChallenge
+	response["postUrl"] = s.URL + "/acs"
+	response["verificationFields"] = []jsoncore.VerificationField{
+		{Name: "creq", Value: base64.RawURLEncoding.EncodeToString([]byte(`{"threeDSServerTransID":"` + session.serverTransID + `","messageVersion":"` + messageVersion + `"}`))},
+		{Name: "threeDSSessionData", Value: md},
+	}
+	return response
+}
+
+// issueCAVV must be called with s.mu held.
+func (s *Server) issueCAVV() string {
+	s.sequence++
+	cavv := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("cavv-%08d", s.sequence)))
+	s.authenticated[cavv] = true
+	return cavv
+}
+
+// Authenticate finishes the session started by /CardVerification as the ACS would.
+// It returns the URL the cardholder's browser posts form to, the AuthorizationSuccessURL
+// when authenticated is true and the AuthorizationFailedURL otherwise.
+// md is the MD field for 3DS1 and threeDSSessionData for 3DS2.
+// ok is false if md is unknown or already used.
+func (s *Server) Authenticate(md string, authenticated bool) (callbackURL string, form url.Values, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.verifications[md]
+	if !ok {
+		return "", nil, false
+	}
+	delete(s.verifications, md)
+
+	form = url.Values{}
+	form.Set("MD", md)
+	form.Set("merchantData", session.merchantData)
+	if session.serverTransID != "" {
+		form.Set("messageVersion", messageVersion)
+		form.Set("threeDSServerTransId", session.serverTransID)
+		form.Set("dsTransId", session.dsTransID)
+		if !authenticated {
+			form.Set("transStatus", string(jsoncore.TransStatusNotAuthenticated))
+			return session.failedURL, form, true
+		}
+		form.Set("transStatus", string(jsoncore.TransStatusAuthenticated))
+		form.Set("eci", "05")
+		form.Set("authenticationValue", s.issueCAVV())
+		return session.successURL, form, true
+	}
+	if !authenticated {
+		return session.failedURL, form, true
+	}
+
+	form.Set("verifyingEnrollmentResponse", "Y")
+	form.Set("payerAuthenticationResponse", "Y")
+	form.Set("cardholderAuthenticationVerificationData", s.issueCAVV())
+	return session.successURL, form, true
+}
+
+var acsForm = template.Must(template.New("acs").Parse(`<!DOCTYPE html>
+<html>
+<body onload="document.forms[0].submit()">
+<form method="POST" action="{{.URL}}">
+{{range $name, $values := .Form}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}</form>
+</body>
+</html>
+`))
+
+// acs authenticates the cardholder and posts the result back, like a real ACS after the
+// cardholder has entered the code from the issuer.
+func (s *Server) acs(w http.ResponseWriter, r *http.Request) {
+	md := r.FormValue("MD")
+	if md == "" {
+		md = r.FormValue("threeDSSessionData")
+	}
+	callbackURL, form, ok := s.Authenticate(md, true)
+	if !ok {
+		http.Error(w, "Unknown MD", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	acsForm.Execute(w, struct {
+		URL  string
+		Form url.Values
+	}{callbackURL, form})
+}
+
+// verificationData checks that data, if any, came from Authenticate.
+func (s *Server) verificationData(v validator, data *jsoncore.CardVerificationData) {
+	if data == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.authenticated[data.CardholderAuthenticationVerificationData] {
+		v["cardVerificationData"] = append(v["cardVerificationData"], "The cardholder was not authenticated.")
+	}
+}
@@ -0,0 +1,93 @@
+package jsoncoretest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	valitor "github.com/opensourcez/go-valitor"
+	"github.com/opensourcez/go-valitor/jsoncore"
+	"github.com/opensourcez/go-valitor/money"
+)
+
+// The agreement the server is used with, it accepts any agreement number and terminal id.
+const (
+	AgreementNumber = "053128"
+	TerminalID      = "225"
+)
+
+// ExpYear is three years from now, test cards that expire then stay valid while the tests run.
+func ExpYear() int {
+	return time.Now().AddDate(3, 0, 0).Year()
+}
+
+// NewService returns a service that sends every request to s with APIKey,
+// opts are applied after the key so they can replace it.
+func (s *Server) NewService(opts ...valitor.Option) *jsoncore.CompanyService {
+	return valitor.NewValitorPayService(AgreementNumber, TerminalID, s.URL, append([]valitor.Option{valitor.WithAPIKey(APIKey)}, opts...)...)
+}
+
+// RunAllOperations calls every operation of service once with its own virtual card and returns the first error.
+func RunAllOperations(ctx context.Context, service *jsoncore.CompanyService) error {
+	amount := money.Money{Amount: 1500, Currency: money.ISK}
+	card := jsoncore.Card{Number: "5304259906522887", ExpYear: ExpYear(), ExpMonth: 11, CVC: "749"}
+
+	virtual := service.CreateVirtualCard(ctx, &card, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "")
+	if virtual.Err() != nil {
+		return virtual.Err()
+	}
+	card.VirtualNumber = virtual.VirtualCard
+	if verified := service.CardPaymentWithVerification(ctx, &card, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", ""); verified.Err() != nil {
+		return verified.Err()
+	}
+	if update := service.UpdateExpirationDate(ctx, &jsoncore.Card{VirtualNumber: card.VirtualNumber, ExpMonth: 5, ExpYear: ExpYear() + 1}, nil, "ECommerceWithCvc"); update.Err() != nil {
+		return update.Err()
+	}
+	offer := service.Dcc(ctx, &card, amount)
+	if offer.Err() != nil {
+		return offer.Err()
+	}
+	if payment := service.CardPayment(ctx, &card, "Sale", "ECommerceWithCvc", amount, "ref-concurrency", "", nil, nil, jsoncore.NewDCCData(&offer)); payment.Err() != nil {
+		return payment.Err()
+	}
+	if payment := service.VirtualCardPayment(ctx, &card, "", amount, "ref-concurrency"); payment.Err() != nil {
+		return payment.Err()
+	}
+	return nil
+}
+
+// RunConcurrently runs RunAllOperations n times at once while the API key of service is
+// rotated to APIKey, every call must see a whole set of settings. It returns the first error.
+func RunConcurrently(service *jsoncore.CompanyService, n int) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	rotated := make(chan struct{})
+	go func() {
+		defer close(rotated)
+		for ctx.Err() == nil {
+			settings := service.Settings()
+			settings.APIKey = APIKey
+			service.SetSettings(settings)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- RunAllOperations(context.Background(), service)
+		}()
+	}
+	wg.Wait()
+	cancel()
+	<-rotated
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
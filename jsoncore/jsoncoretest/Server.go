@@ -0,0 +1,453 @@
+// Package jsoncoretest provides an in-process ValitorPay server for tests,
+// so jsoncore can be exercised without access to uat.valitorpay.com.
+package jsoncoretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensourcez/go-valitor/jsoncore"
+)
+
+// APIKey is the key the server accepts unless Server.APIKey is changed.
+const APIKey = "jsoncoretest-api-key"
+
+// Scenario decides how the server answers a request.
+type Scenario int
+
+const (
+	// Approve answers with a successful response.
+	Approve Scenario = iota
+	// Decline answers 200 with isSuccess false and Server.DeclineCode.
+	Decline
+	// Unauthorized answers 401 with an empty body.
+	Unauthorized
+	// ValidationError answers 400 with RFC 7807 problem details.
+	ValidationError
+	// Timeout holds the request until the client gives up or Server.TimeoutDelay passes.
+	Timeout
+	// Unavailable answers 503 with an empty body.
+	Unavailable
+)
+
+func (s Scenario) String() string {
+	switch s {
+	case Approve:
+		return "Approve"
+	case Decline:
+		return "Decline"
+	case Unauthorized:
+		return "Unauthorized"
+	case ValidationError:
+		return "ValidationError"
+	case Timeout:
+		return "Timeout"
+	case Unavailable:
+		return "Unavailable"
+	}
+	return fmt.Sprintf("Scenario(%d)", int(s))
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Server is a fake ValitorPay API implementing
+// /VirtualCard/CreateVirtualCard, /VirtualCard/UpdateExpirationDate,
+// /Payment/CardPayment, /Payment/VirtualCardPayment, /Payment/Capture,
+// /Payment/Reversal, /Payment/Refund, /Dcc and /CardVerification,
+// with a fake ACS at /acs for 3-D Secure.
+type Server struct {
+	*httptest.Server
+
+	// APIKey is the key expected in the Authorization header.
+	// If it is empty any key is accepted.
+	APIKey string
+	// DeclineCode and DeclineDescription are used by the Decline scenario.
+	DeclineCode        string
+	DeclineDescription string
+	// TimeoutDelay is the longest the Timeout scenario holds a request.
+	TimeoutDelay time.Duration
+
+	mu        sync.Mutex
+	scenario  Scenario
+	scenarios map[string]Scenario
+	times     map[string]scenarioTimes
+	requests  []Request
+	// verifications are the 3-D Secure sessions by MD, authenticated holds the CAVVs given out.
+	verifications map[string]verification
+	authenticated map[string]bool
+	// transactions are the payments by TransactionID and TransactionLifecycleID.
+	transactions map[string]*transaction
+	challenge    bool
+	sequence     int
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+// NewServer starts a server that approves every request.
+// The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		APIKey:             APIKey,
+		DeclineCode:        "05",
+		DeclineDescription: "Do not honor",
+		TimeoutDelay:       time.Minute,
+		scenarios:          make(map[string]Scenario),
+		times:              make(map[string]scenarioTimes),
+		verifications:      make(map[string]verification),
+		authenticated:      make(map[string]bool),
+		transactions:       make(map[string]*transaction),
+		closed:             make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/VirtualCard/CreateVirtualCard", s.handle(s.createVirtualCard))
+	mux.HandleFunc("/VirtualCard/UpdateExpirationDate", s.handle(s.updateExpirationDate))
+	mux.HandleFunc("/Payment/CardPayment", s.handle(s.cardPayment))
+	mux.HandleFunc("/Payment/VirtualCardPayment", s.handle(s.virtualCardPayment))
+	mux.HandleFunc("/Payment/Capture", s.handle(s.capture))
+	mux.HandleFunc("/Payment/Reversal", s.handle(s.reversal))
+	mux.HandleFunc("/Payment/Refund", s.handle(s.refund))
+	mux.HandleFunc("/Dcc", s.handle(s.dcc))
+	mux.HandleFunc("/CardVerification", s.handle(s.cardVerification))
+	mux.HandleFunc("/acs", s.acs)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Close releases requests held by the Timeout scenario and shuts the server down.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+	s.Server.Close()
+}
+
+// SetScenario sets the scenario used for every path without its own scenario.
+func (s *Server) SetScenario(scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario = scenario
+}
+
+// SetScenarioFor sets the scenario for a single path, for example "/Payment/CardPayment".
+func (s *Server) SetScenarioFor(path string, scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenarios[path] = scenario
+}
+
+// SetDecline sets the responseCode and description the Decline scenario answers with,
+// use it instead of DeclineCode and DeclineDescription once the server is running.
+func (s *Server) SetDecline(code, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.DeclineCode, s.DeclineDescription = code, description
+}
+
+type scenarioTimes struct {
+	scenario Scenario
+	left     int
+}
+
+// SetScenarioTimes answers the next n requests to path with scenario,
+// after that the path is answered as before. Use it to make a call fail and then succeed.
+func (s *Server) SetScenarioTimes(path string, scenario Scenario, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.times[path] = scenarioTimes{scenario: scenario, left: n}
+}
+
+// Reset approves every request again and forgets the received requests, 3-D Secure sessions and payments.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario = Approve
+	s.scenarios = make(map[string]Scenario)
+	s.times = make(map[string]scenarioTimes)
+	s.requests = nil
+	s.verifications = make(map[string]verification)
+	s.authenticated = make(map[string]bool)
+	s.challenge = false
+	s.transactions = make(map[string]*transaction)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest returns the last request received for path.
+func (s *Server) LastRequest(path string) (request Request, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		if s.requests[i].Path == path {
+			return s.requests[i], true
+		}
+	}
+	return
+}
+
+// =====================================================
+//
+// REQUEST HANDLING
+//
+// =====================================================
+
+type approveFunc func(body []byte) (response interface{}, problems map[string][]string)
+
+func (s *Server) handle(approve approveFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		scenario := s.record(r, body)
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if s.APIKey != "" && r.Header.Get("Authorization") != "APIKey "+s.APIKey {
+			scenario = Unauthorized
+		}
+
+		switch scenario {
+		case Unauthorized:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case Unavailable:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case Timeout:
+			select {
+			case <-r.Context().Done():
+			case <-s.closed:
+			case <-time.After(s.TimeoutDelay):
+			}
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		case ValidationError:
+			writeProblem(w, map[string][]string{"request": {"The request is invalid."}})
+			return
+		case Decline:
+			s.mu.Lock()
+			code, description := s.DeclineCode, s.DeclineDescription
+			s.mu.Unlock()
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"isSuccess":           false,
+				"responseCode":        code,
+				"responseDescription": description,
+			})
+			return
+		}
+
+		response, problems := approve(body)
+		if len(problems) > 0 {
+			writeProblem(w, problems)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+func (s *Server) record(r *http.Request, body []byte) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	if times, ok := s.times[r.URL.Path]; ok && times.left > 0 {
+		times.left--
+		s.times[r.URL.Path] = times
+		return times.scenario
+	}
+	if scenario, ok := s.scenarios[r.URL.Path]; ok {
+		return scenario
+	}
+	return s.scenario
+}
+
+func (s *Server) next() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequence++
+	return s.sequence
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeProblem(w http.ResponseWriter, problems map[string][]string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(jsoncore.ProblemDetails{
+		Type:   "https://tools.ietf.org/html/rfc7231#section-6.5.1",
+		Title:  "One or more validation errors occurred.",
+		Status: http.StatusBadRequest,
+		Errors: problems,
+	})
+}
+
+// =====================================================
+//
+// OPERATIONS
+//
+// =====================================================
+
+type validator map[string][]string
+
+func (v validator) require(field string, ok bool) {
+	if !ok {
+		v[field] = append(v[field], "The "+field+" field is required.")
+	}
+}
+
+func (v validator) decode(body []byte, request interface{}) bool {
+	if err := json.Unmarshal(body, request); err != nil {
+		v["body"] = append(v["body"], err.Error())
+		return false
+	}
+	return true
+}
+
+// cardNumber rejects card numbers that are not only digits, so a number sent without card.Normalize fails.
+func (v validator) cardNumber(number string) {
+	v.require("cardNumber", number != "")
+	if strings.Trim(number, "0123456789") != "" {
+		v["cardNumber"] = append(v["cardNumber"], "The cardNumber field must only contain digits.")
+	}
+}
+
+func (v validator) expiry(month, year int) {
+	v.require("expirationMonth", month >= 1 && month <= 12)
+	v.require("expirationYear", year >= 2000 && year <= 2099)
+}
+
+func (s *Server) createVirtualCard(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.VirtualCardRequest
+	v := validator{}
+	if v.decode(body, &request) {
+		v.cardNumber(request.CardNumber)
+		v.require("cvc", request.Cvc != "")
+		v.require("transactionType", request.TransactionType != "")
+		v.expiry(request.ExpirationMonth, request.ExpirationYear)
+		s.verificationData(v, request.CardVerificationData)
+	}
+	if len(v) > 0 {
+		return nil, v
+	}
+	return map[string]interface{}{
+		"virtualCard":         fmt.Sprintf("4999%012d", s.next()),
+		"isSuccess":           true,
+		"responseCode":        "00",
+		"responseDescription": "Approved",
+	}, nil
+}
+
+func (s *Server) updateExpirationDate(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.VirtualCardExpirationUpdateRequest
+	v := validator{}
+	if v.decode(body, &request) {
+		v.require("virtualCardNumber", request.VirtualCardNumber != "")
+		v.expiry(request.ExpirationMonth, request.ExpirationYear)
+	}
+	if len(v) > 0 {
+		return nil, v
+	}
+	return approved(), nil
+}
+
+func (s *Server) cardPayment(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.CardPaymentRequest
+	v := validator{}
+	if v.decode(body, &request) {
+		v.cardNumber(request.CardNumber)
+		v.require("operation", request.Operation.Validate() == nil)
+		v.require("currency", request.Currency != "")
+		v.require("amount", request.Amount > 0)
+		v.expiry(request.ExpirationMonth, request.ExpirationYear)
+		s.verificationData(v, request.CardVerificationData)
+	}
+	if len(v) > 0 {
+		return nil, v
+	}
+	return s.payment(request.ReferenceNumber, request.Operation, request.Amount), nil
+}
+
+func (s *Server) virtualCardPayment(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.VirtualCardPaymentRequest
+	v := validator{}
+	if v.decode(body, &request) {
+		v.require("operation", request.Operation.Validate() == nil)
+		v.require("virtualCardNumber", request.VirtualCardNumber != "")
+		v.require("currency", request.Currency != "")
+		v.require("amount", request.Amount > 0)
+	}
+	if len(v) > 0 {
+		return nil, v
+	}
+	return s.payment(request.ReferenceNumber, request.Operation, request.Amount), nil
+}
+
+func (s *Server) dcc(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.DCCOfferRequest
+	v := validator{}
+	if v.decode(body, &request) {
+		v.cardNumber(request.CardNumber)
+		v.require("currency", request.Currency != "")
+		v.require("amount", request.Amount > 0)
+	}
+	if len(v) > 0 {
+		return nil, v
+	}
+	const rate = 0.0072
+	return map[string]interface{}{
+		"currency":                     request.Currency,
+		"amount":                       request.Amount,
+		"offerCurrency":                "EUR",
+		"offerAmount":                  int(float64(request.Amount) * rate),
+		"dccCardholderBillingFee":      0,
+		"exchangeRate":                 rate,
+		"dccInformationEncryptedValue": fmt.Sprintf("dcc-%d", s.next()),
+		"responseTimestamp":            time.Now().UTC().Format(time.RFC3339),
+		"isSuccess":                    true,
+		"responseCode":                 "00",
+		"responseDescription":          "Approved",
+	}, nil
+}
+
+func (s *Server) payment(referenceNumber string, operation jsoncore.Operation, amount int) map[string]interface{} {
+	id := s.next()
+	lifecycleID := fmt.Sprintf("lifecycle-%d", id)
+	response := s.receipt(id, referenceNumber, lifecycleID)
+	s.remember(response["transactionID"].(string), lifecycleID, operation, amount)
+	return response
+}
+
+func (s *Server) receipt(id int, referenceNumber, lifecycleID string) map[string]interface{} {
+	response := approved()
+	response["referenceNumber"] = referenceNumber
+	response["transactionID"] = fmt.Sprintf("%012d", id)
+	response["authorizationCode"] = strings.ToUpper(fmt.Sprintf("A%05X", id))
+	response["transactionLifecycleId"] = lifecycleID
+	response["authorizationResponseTime"] = time.Now().UTC().Format(time.RFC3339)
+	return response
+}
+
+func approved() map[string]interface{} {
+	return map[string]interface{}{
+		"isSuccess":           true,
+		"responseCode":        "00",
+		"responseDescription": "Approved",
+	}
+}
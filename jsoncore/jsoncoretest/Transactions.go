@@ -0,0 +1,142 @@
+package jsoncoretest
+
+import (
+	"github.com/opensourcez/go-valitor/jsoncore"
+)
+
+// =====================================================
+//
+// CAPTURE, REVERSAL AND REFUND
+//
+// Every approved payment is remembered, so the server refuses
+// to capture more than was authorized, to reverse a captured
+// payment or to refund more than was captured.
+//
+// =====================================================
+
+type transaction struct {
+	lifecycleID   string
+	authorization bool
+	amount        int
+	captured      int
+	refunded      int
+	reversed      bool
+}
+
+// settled is what the cardholder has paid and can get back.
+func (t *transaction) settled() int {
+	if t.authorization {
+		return t.captured
+	}
+	return t.amount
+}
+
+func (s *Server) remember(transactionID, lifecycleID string, operation jsoncore.Operation, amount int) {
+	t := &transaction{lifecycleID: lifecycleID, authorization: operation == jsoncore.OperationAuthorization, amount: amount}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions[transactionID] = t
+	s.transactions[lifecycleID] = t
+}
+
+// original finds the payment by TransactionLifecycleID or TransactionID, it must be called with s.mu held.
+func (s *Server) original(v validator, transactionID, lifecycleID string) *transaction {
+	if t, ok := s.transactions[lifecycleID]; ok && lifecycleID != "" {
+		return t
+	}
+	if t, ok := s.transactions[transactionID]; ok && transactionID != "" {
+		return t
+	}
+	v["transactionID"] = append(v["transactionID"], "The transaction was not found.")
+	return nil
+}
+
+// followUp answers a capture, reversal or refund in the lifecycle of the original payment.
+func (s *Server) followUp(referenceNumber string, original *transaction) map[string]interface{} {
+	return s.receipt(s.next(), referenceNumber, original.lifecycleID)
+}
+
+func (s *Server) capture(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.CaptureRequest
+	v := validator{}
+	if !v.decode(body, &request) {
+		return nil, v
+	}
+	v.require("amount", request.Amount > 0)
+
+	s.mu.Lock()
+	t := s.original(v, request.TransactionID, request.TransactionLifecycleID)
+	if t != nil {
+		switch {
+		case !t.authorization:
+			v["transactionID"] = append(v["transactionID"], "Only an authorization can be captured.")
+		case t.reversed:
+			v["transactionID"] = append(v["transactionID"], "The authorization was reversed.")
+		case t.captured+request.Amount > t.amount:
+			v["amount"] = append(v["amount"], "The amount is more than was authorized.")
+		case len(v) == 0:
+			t.captured += request.Amount
+		}
+	}
+	s.mu.Unlock()
+
+	if len(v) > 0 {
+		return nil, v
+	}
+	return s.followUp(request.ReferenceNumber, t), nil
+}
+
+func (s *Server) reversal(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.ReversalRequest
+	v := validator{}
+	if !v.decode(body, &request) {
+		return nil, v
+	}
+
+	s.mu.Lock()
+	t := s.original(v, request.TransactionID, request.TransactionLifecycleID)
+	if t != nil {
+		switch {
+		case t.reversed:
+			v["transactionID"] = append(v["transactionID"], "The transaction was already reversed.")
+		case t.captured > 0 || t.refunded > 0:
+			v["transactionID"] = append(v["transactionID"], "The transaction is settled, refund it instead.")
+		default:
+			t.reversed = true
+		}
+	}
+	s.mu.Unlock()
+
+	if len(v) > 0 {
+		return nil, v
+	}
+	return s.followUp(request.ReferenceNumber, t), nil
+}
+
+func (s *Server) refund(body []byte) (interface{}, map[string][]string) {
+	var request jsoncore.RefundRequest
+	v := validator{}
+	if !v.decode(body, &request) {
+		return nil, v
+	}
+	v.require("amount", request.Amount > 0)
+
+	s.mu.Lock()
+	t := s.original(v, request.TransactionID, request.TransactionLifecycleID)
+	if t != nil {
+		switch {
+		case t.reversed:
+			v["transactionID"] = append(v["transactionID"], "The transaction was reversed.")
+		case t.refunded+request.Amount > t.settled():
+			v["amount"] = append(v["amount"], "The amount is more than was paid.")
+		case len(v) == 0:
+			t.refunded += request.Amount
+		}
+	}
+	s.mu.Unlock()
+
+	if len(v) > 0 {
+		return nil, v
+	}
+	return s.followUp(request.ReferenceNumber, t), nil
+}
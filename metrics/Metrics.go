@@ -0,0 +1,152 @@
+// Package metrics collects Prometheus metrics for calls to valitor.
+//
+// A Collector is shared by any number of services, pass it to them with
+// valitor.WithMetrics and register it once:
+//
+//	collector := metrics.New()
+//	prometheus.MustRegister(collector)
+//	service := valitor.NewValitorPayService(..., valitor.WithMetrics(collector))
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Core label values.
+const (
+	CoreXML  = "xml"
+	CoreJSON = "json"
+)
+
+// Outcome label values, also used for the valitor.outcome span attribute by both cores.
+const (
+	// OutcomeSuccess is a call valitor answered without an error.
+	OutcomeSuccess = "success"
+	// OutcomeValitorError is a call valitor answered with a Villunumer or responseCode, declines included.
+	OutcomeValitorError = "valitor_error"
+	// OutcomeSystemError is a call that got no usable answer, a network, HTTP or parsing error.
+	OutcomeSystemError = "system_error"
+)
+
+// DefaultBuckets are the latency buckets in seconds, valitor usually answers within a second
+// but FaHeimild and CardPayment can take much longer when the card issuer is slow.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 32}
+
+// Call is one finished call to valitor.
+type Call struct {
+	// Core is CoreXML or CoreJSON.
+	Core string
+	// Operation is FaHeimild, CardPayment, ...
+	Operation string
+	// Outcome is OutcomeSuccess, OutcomeValitorError or OutcomeSystemError.
+	Outcome string
+	// ErrorCode is the Villunumer or responseCode of a Valitor error or decline.
+	ErrorCode string
+	// StatusCode is 0 if no response was received.
+	StatusCode int
+	Duration   time.Duration
+}
+
+// Collector is a prometheus.Collector with the metrics below, all labeled by core and operation.
+//
+//	valitor_requests_total{outcome}
+//	valitor_request_duration_seconds
+//	valitor_errors_total{error_code}
+//	valitor_http_responses_total{status}
+//	valitor_retries_total
+//
+// The decline rate of an operation is valitor_errors_total / valitor_requests_total.
+// All methods can be called on a nil Collector and do nothing.
+type Collector struct {
+	requests  *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	errors    *prometheus.CounterVec
+	responses *prometheus.CounterVec
+	retries   *prometheus.CounterVec
+}
+
+// New creates a Collector with DefaultBuckets.
+func New() *Collector {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets creates a Collector with custom latency buckets in seconds.
+func NewWithBuckets(buckets []float64) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "valitor_requests_total",
+			Help: "Calls to valitor by outcome.",
+		}, []string{"core", "operation", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "valitor_request_duration_seconds",
+			Help:    "Time until valitor answered, or the call failed.",
+			Buckets: buckets,
+		}, []string{"core", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "valitor_errors_total",
+			Help: "Valitor errors and declines by error code.",
+		}, []string{"core", "operation", "error_code"}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "valitor_http_responses_total",
+			Help: "HTTP responses from valitor by status code, none if no response was received.",
+		}, []string{"core", "operation", "status"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "valitor_retries_total",
+			Help: "Calls to valitor that were sent again.",
+		}, []string{"core", "operation"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.errors.Describe(ch)
+	c.responses.Describe(ch)
+	c.retries.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+	c.responses.Collect(ch)
+	c.retries.Collect(ch)
+}
+
+// Register registers the collector on r, prometheus.DefaultRegisterer is used if r is nil.
+func (c *Collector) Register(r prometheus.Registerer) error {
+	if r == nil {
+		r = prometheus.DefaultRegisterer
+	}
+	return r.Register(c)
+}
+
+// Observe records a finished call.
+func (c *Collector) Observe(call Call) {
+	if c == nil {
+		return
+	}
+	c.requests.WithLabelValues(call.Core, call.Operation, call.Outcome).Inc()
+	c.duration.WithLabelValues(call.Core, call.Operation).Observe(call.Duration.Seconds())
+	if call.ErrorCode != "" {
+		c.errors.WithLabelValues(call.Core, call.Operation, call.ErrorCode).Inc()
+	}
+	status := "none"
+	if call.StatusCode != 0 {
+		status = strconv.Itoa(call.StatusCode)
+	}
+	c.responses.WithLabelValues(call.Core, call.Operation, status).Inc()
+}
+
+// ObserveRetry records that a call is about to be sent again.
+func (c *Collector) ObserveRetry(core, operation string) {
+	if c == nil {
+		return
+	}
+	c.retries.WithLabelValues(core, operation).Inc()
+}
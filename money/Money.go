@@ -0,0 +1,178 @@
+package money
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// =====================================================
+//
+// MONEY
+//
+// Amounts are always kept in minor units (ISK has none,
+// EUR has cents) together with the ISO 4217 currency code.
+//
+// Documentation: https://www.iso.org/iso-4217-currency-codes.html
+//
+// =====================================================
+
+var (
+	ErrCurrencyMissing = errors.New("Currency missing")
+	ErrAmountMissing   = errors.New("Amount missing")
+	ErrNegativeAmount  = errors.New("Amount can not be negative")
+	ErrInvalidAmount   = errors.New("Invalid amount")
+)
+
+// UnknownCurrencyError is returned for currency codes that are not in the ISO 4217 table below.
+type UnknownCurrencyError struct {
+	Code string
+}
+
+func (e *UnknownCurrencyError) Error() string {
+	return "Unknown currency: " + e.Code
+}
+
+// Currency is an ISO 4217 alphabetic currency code.
+type Currency string
+
+const (
+	ISK Currency = "ISK"
+	EUR Currency = "EUR"
+	USD Currency = "USD"
+	GBP Currency = "GBP"
+	DKK Currency = "DKK"
+	NOK Currency = "NOK"
+	SEK Currency = "SEK"
+)
+
+// exponents holds the number of minor unit digits for each currency.
+var exponents = map[Currency]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2,
+	"IDR": 2, "ILS": 2, "INR": 2, "IQD": 3, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "LYD": 3,
+	"MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PHP": 2, "PLN": 2, "RON": 2, "RUB": 2, "SAR": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2,
+	"TWD": 2, "UAH": 2, "UGX": 0, "USD": 2, "VND": 0,
+	"XAF": 0, "XOF": 0, "ZAR": 2,
+}
+
+// ParseCurrency returns the Currency for an ISO 4217 code, upper or lower case.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if err := currency.Validate(); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+// Validate checks that the currency is a known ISO 4217 code.
+func (c Currency) Validate() error {
+	if c == "" {
+		return ErrCurrencyMissing
+	}
+	if _, ok := exponents[c]; !ok {
+		return &UnknownCurrencyError{Code: string(c)}
+	}
+	return nil
+}
+
+// Exponent returns the number of minor unit digits, 0 for ISK and 2 for EUR.
+func (c Currency) Exponent() int {
+	return exponents[c]
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Money ...
+// Amount is in minor units of Currency, 100 EUR is Money{Amount: 10000, Currency: EUR}
+// and 100 ISK is Money{Amount: 100, Currency: ISK}.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// New returns Money for an amount in minor units.
+func New(minorUnits int64, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	m := Money{Amount: minorUnits, Currency: c}
+	return m, m.Validate()
+}
+
+// Parse returns Money for a decimal amount in major units, for example "12.50" EUR.
+// The amount may not have more decimals than the currency allows.
+func Parse(major string, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	major = strings.TrimSpace(major)
+	whole, fraction := major, ""
+	if i := strings.IndexAny(major, ".,"); i >= 0 {
+		whole, fraction = major[:i], major[i+1:]
+	}
+	if len(fraction) > c.Exponent() {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", c.Exponent()-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	m := Money{Amount: minor, Currency: c}
+	return m, m.Validate()
+}
+
+// Validate checks the currency and that the amount is positive.
+func (m Money) Validate() error {
+	if err := m.Currency.Validate(); err != nil {
+		return err
+	}
+	if m.Amount == 0 {
+		return ErrAmountMissing
+	}
+	if m.Amount < 0 {
+		return ErrNegativeAmount
+	}
+	return nil
+}
+
+// MinorUnits returns the amount in minor units, this is what ValitorPay expects.
+func (m Money) MinorUnits() int64 {
+	return m.Amount
+}
+
+// Major returns the amount as a decimal string in major units,
+// "100" for 100 ISK and "12.50" for 1250 EUR. This is what the XML service expects.
+func (m Money) Major() string {
+	exponent := m.Currency.Exponent()
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Major() + " " + string(m.Currency)
+}
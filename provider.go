@@ -0,0 +1,313 @@
+package valitor
+
+import (
+	"context"
+	"errors"
+
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+)
+
+// =====================================================
+//
+// PAYMENT PROVIDER
+//
+// A backend neutral API over the old XML service and the new
+// ValitorPay JSON service. Switching a merchant between the two
+// is a matter of changing ProviderConfig.Backend.
+//
+// =====================================================
+
+// ErrNotSupported is returned when the backend has no matching operation.
+var ErrNotSupported = errors.New("Operation not supported by this backend")
+
+// ErrUnknownBackend is returned by NewPaymentProvider for an unknown Backend.
+var ErrUnknownBackend = errors.New("Unknown backend")
+
+// Backend ...
+type Backend string
+
+const (
+	// BackendXML uses the old SOAP service, see NewValitorService.
+	BackendXML Backend = "xml"
+	// BackendJSON uses ValitorPay, see NewValitorPayService.
+	BackendJSON Backend = "json"
+)
+
+// Card ...
+// Token is the virtual number returned by Tokenize,
+// store it instead of the card number.
+type Card struct {
+	Number   string
+	ExpMonth int
+	ExpYear  int
+	CVC      string
+	Token    string
+}
+
+// Payment ...
+type Payment struct {
+	Amount    money.Money
+	Reference string
+}
+
+// Transaction ...
+// Returned by Authorize, Capture, Refund and Void.
+// Pass the Transaction from Authorize to Capture and Void.
+type Transaction struct {
+	TransactionID          string
+	AuthorizationCode      string
+	TransactionLifecycleID string
+	Amount                 money.Money
+	// Response is the xmlcore or jsoncore response the transaction was read from.
+	Response interface{}
+}
+
+// PaymentProvider ...
+// Implemented by NewXMLProvider and NewJSONProvider.
+type PaymentProvider interface {
+	// Tokenize turns a real card into a virtual number.
+	Tokenize(ctx context.Context, card *Card) (token string, err error)
+	// Authorize reserves the amount on the card without capturing it.
+	Authorize(ctx context.Context, card *Card, payment Payment) (Transaction, error)
+	// Capture captures an authorization returned by Authorize.
+	Capture(ctx context.Context, card *Card, authorization Transaction) (Transaction, error)
+	// Refund pays the amount back to the card.
+	Refund(ctx context.Context, card *Card, original Transaction, payment Payment) (Transaction, error)
+	// Void cancels a transaction that has not been settled.
+	Void(ctx context.Context, card *Card, transaction Transaction) (Transaction, error)
+	// UpdateExpiry sets a new expiration date on the virtual number.
+	UpdateExpiry(ctx context.Context, card *Card) error
+	// LastFour returns the last four digits of the real card behind the virtual number.
+	LastFour(ctx context.Context, card *Card) (string, error)
+}
+
+// ProviderConfig ...
+// Only the fields for the chosen Backend are used.
+// If URL is "" the testing url of the backend is used.
+type ProviderConfig struct {
+	Backend Backend
+	URL     string
+
+	// BackendXML
+	Username               string
+	Password               string
+	ContractNumber         string
+	ContractIdentidyNumber string
+	PosID                  string
+
+	// BackendJSON
+	AgreementNumber string
+	TerminalID      string
+	APIKey          string
+}
+
+// NewPaymentProvider ...
+// Creates the service for config.Backend and wraps it in a PaymentProvider.
+func NewPaymentProvider(config ProviderConfig, opts ...Option) (PaymentProvider, error) {
+	switch config.Backend {
+	case BackendXML:
+		return NewXMLProvider(NewValitorService(
+			config.Username,
+			config.Password,
+			config.ContractNumber,
+			config.ContractIdentidyNumber,
+			config.PosID,
+			config.URL,
+			opts...,
+		)), nil
+	case BackendJSON:
+		if config.APIKey != "" {
+			opts = append([]Option{WithAPIKey(config.APIKey)}, opts...)
+		}
+		return NewJSONProvider(NewValitorPayService(
+			config.AgreementNumber,
+			config.TerminalID,
+			config.URL,
+			opts...,
+		)), nil
+	default:
+		return nil, ErrUnknownBackend
+	}
+}
+
+// =====================================================
+//
+// XML BACKEND
+//
+// =====================================================
+
+type xmlProvider struct {
+	service *xmlcore.CompanyService
+}
+
+// NewXMLProvider ...
+// Authorize uses FaAdeinsheimild and Capture uses NotaAdeinsheimild,
+// both need the CVC on the card.
+func NewXMLProvider(service *xmlcore.CompanyService) PaymentProvider {
+	return &xmlProvider{service: service}
+}
+
+func (p *xmlProvider) card(card *Card) *xmlcore.Card {
+	return &xmlcore.Card{
+		Number:        card.Number,
+		ExpMonth:      card.ExpMonth,
+		ExpYear:       card.ExpYear,
+		CVC:           card.CVC,
+		VirtualNumber: card.Token,
+	}
+}
+
+func xmlTransaction(receipt xmlcore.Receipt, amount money.Money, response interface{}) Transaction {
+	return Transaction{
+		TransactionID:     receipt.TransactionID,
+		AuthorizationCode: receipt.AuthorizationID,
+		Amount:            amount,
+		Response:          response,
+	}
+}
+
+func (p *xmlProvider) Tokenize(ctx context.Context, card *Card) (string, error) {
+	response := p.service.FaSyndarkortnumer(ctx, p.card(card))
+	if err := response.Err(); err != nil {
+		return "", err
+	}
+	return response.VirtualNumber, nil
+}
+
+func (p *xmlProvider) Authorize(ctx context.Context, card *Card, payment Payment) (Transaction, error) {
+	response := p.service.FaAdeinsHeimild(ctx, p.card(card), payment.Amount)
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return xmlTransaction(response.Receipt, payment.Amount, response), nil
+}
+
+func (p *xmlProvider) Capture(ctx context.Context, card *Card, authorization Transaction) (Transaction, error) {
+	response := p.service.NotaAdeinsheimild(ctx, p.card(card), authorization.TransactionID)
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	captured := authorization
+	captured.Response = response
+	return captured, nil
+}
+
+func (p *xmlProvider) Refund(ctx context.Context, card *Card, original Transaction, payment Payment) (Transaction, error) {
+	response := p.service.FaEndurgreitt(ctx, p.card(card), payment.Amount)
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return xmlTransaction(response.Receipt, payment.Amount, response), nil
+}
+
+func (p *xmlProvider) Void(ctx context.Context, card *Card, transaction Transaction) (Transaction, error) {
+	response := p.service.FaOgildingu(ctx, p.card(card), transaction.Amount.Currency, transaction.TransactionID)
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return xmlTransaction(response.Receipt, transaction.Amount, response), nil
+}
+
+func (p *xmlProvider) UpdateExpiry(ctx context.Context, card *Card) error {
+	return p.service.UppfaeraGildistima(ctx, p.card(card)).Err()
+}
+
+func (p *xmlProvider) LastFour(ctx context.Context, card *Card) (string, error) {
+	response := p.service.FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(ctx, p.card(card))
+	if err := response.Err(); err != nil {
+		return "", err
+	}
+	return response.Kortnumer, nil
+}
+
+// =====================================================
+//
+// JSON BACKEND
+//
+// =====================================================
+
+const (
+	jsonSubsequentTransactionType = jsoncore.SubsequentTransactionCardholderInitiatedCredentialOnFile
+	jsonTransactionType           = jsoncore.TransactionTypeECommerceWithCvc
+)
+
+type jsonProvider struct {
+	service *jsoncore.CompanyService
+}
+
+// NewJSONProvider ...
+// Authorize uses VirtualCardAuthorization, Capture, Refund and Void find it again
+// by TransactionLifecycleID. ValitorPay has no endpoint for LastFour, it returns ErrNotSupported.
+func NewJSONProvider(service *jsoncore.CompanyService) PaymentProvider {
+	return &jsonProvider{service: service}
+}
+
+func (p *jsonProvider) card(card *Card) *jsoncore.Card {
+	return &jsoncore.Card{
+		Number:        card.Number,
+		ExpMonth:      card.ExpMonth,
+		ExpYear:       card.ExpYear,
+		CVC:           card.CVC,
+		VirtualNumber: card.Token,
+	}
+}
+
+func jsonTransaction(transactionID, authorizationCode, transactionLifecycleID string, amount money.Money, response interface{}) Transaction {
+	return Transaction{
+		TransactionID:          transactionID,
+		AuthorizationCode:      authorizationCode,
+		TransactionLifecycleID: transactionLifecycleID,
+		Amount:                 amount,
+		Response:               response,
+	}
+}
+
+func (p *jsonProvider) Tokenize(ctx context.Context, card *Card) (string, error) {
+	response := p.service.CreateVirtualCard(ctx, p.card(card), nil, jsonSubsequentTransactionType, jsonTransactionType, "")
+	if err := response.Err(); err != nil {
+		return "", err
+	}
+	return response.VirtualCard, nil
+}
+
+func (p *jsonProvider) Authorize(ctx context.Context, card *Card, payment Payment) (Transaction, error) {
+	response := p.service.VirtualCardAuthorization(ctx, p.card(card), "", payment.Amount, payment.Reference)
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return jsonTransaction(response.TransactionID, response.AuthorizationCode, response.TransactionLifecycleID, payment.Amount, response), nil
+}
+
+func (p *jsonProvider) Capture(ctx context.Context, card *Card, authorization Transaction) (Transaction, error) {
+	response := p.service.Capture(ctx, authorization.TransactionID, authorization.TransactionLifecycleID, authorization.Amount, "")
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return jsonTransaction(response.TransactionID, response.AuthorizationCode, response.TransactionLifecycleID, authorization.Amount, response), nil
+}
+
+func (p *jsonProvider) Refund(ctx context.Context, card *Card, original Transaction, payment Payment) (Transaction, error) {
+	response := p.service.Refund(ctx, original.TransactionID, original.TransactionLifecycleID, payment.Amount, payment.Reference)
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return jsonTransaction(response.TransactionID, response.AuthorizationCode, response.TransactionLifecycleID, payment.Amount, response), nil
+}
+
+func (p *jsonProvider) Void(ctx context.Context, card *Card, transaction Transaction) (Transaction, error) {
+	response := p.service.Reversal(ctx, transaction.TransactionID, transaction.TransactionLifecycleID, transaction.Amount, "")
+	if err := response.Err(); err != nil {
+		return Transaction{Response: response}, err
+	}
+	return jsonTransaction(response.TransactionID, response.AuthorizationCode, response.TransactionLifecycleID, transaction.Amount, response), nil
+}
+
+func (p *jsonProvider) UpdateExpiry(ctx context.Context, card *Card) error {
+	return p.service.UpdateExpirationDate(ctx, p.card(card), nil, jsonTransactionType).Err()
+}
+
+func (p *jsonProvider) LastFour(ctx context.Context, card *Card) (string, error) {
+	return "", ErrNotSupported
+}
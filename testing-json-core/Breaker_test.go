@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func Test_Breaker_OpensAndProbes(t *testing.T) {
+	defer Simulator.Reset()
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	service := Simulator.NewService(valitor.WithCircuitBreaker(cb))
+	amount := money.Money{Amount: 1500, Currency: money.ISK}
+	endpoint := Simulator.URL + "/Dcc"
+
+	Simulator.SetScenarioFor("/Dcc", jsoncoretest.Unavailable)
+	service.Dcc(context.Background(), TestCardJSON, amount)
+	service.Dcc(context.Background(), TestCardJSON, amount)
+	response := service.Dcc(context.Background(), TestCardJSON, amount)
+	var open *helpers.CircuitOpenError
+	if !errors.Is(response.Err(), helpers.ErrCircuitOpen) || !errors.As(response.Err(), &open) || open.Endpoint != endpoint || requestsTo("/Dcc") != 2 {
+		t.Log("Expected the circuit to open after two failures, got:", response.Err(), requestsTo("/Dcc"))
+		t.Fatal()
+	}
+
+	payment := service.VirtualCardPayment(context.Background(), TestCardJSON, "", amount, "ref-breaker")
+	if payment.Err() != nil {
+		t.Log("Expected other endpoints to be unaffected, got:", payment.Err())
+		t.Fatal()
+	}
+
+	Simulator.SetScenarioFor("/Dcc", jsoncoretest.Approve)
+	time.Sleep(60 * time.Millisecond)
+	if cb.State(endpoint) != helpers.CircuitHalfOpen {
+		t.Log("Expected the circuit to be half open, got:", cb.State(endpoint))
+		t.Fatal()
+	}
+	response = service.Dcc(context.Background(), TestCardJSON, amount)
+	if response.Err() != nil || cb.State(endpoint) != helpers.CircuitClosed {
+		t.Log("Expected the probe to close the circuit, got:", response.Err(), cb.State(endpoint))
+		t.Fatal()
+	}
+}
+
+func Test_Breaker_DeclinesKeepCircuitClosed(t *testing.T) {
+	defer Simulator.Reset()
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{FailureThreshold: 1})
+	service := Simulator.NewService(valitor.WithCircuitBreaker(cb))
+
+	Simulator.SetScenario(jsoncoretest.Decline)
+	for i := 0; i < 3; i++ {
+		service.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-breaker")
+	}
+	if state := cb.State(Simulator.URL + "/Payment/VirtualCardPayment"); state != helpers.CircuitClosed {
+		t.Log("Expected declines to keep the circuit closed, got:", state)
+		t.Fatal()
+	}
+}
+
+func Test_Breaker_RetryableCodeOpensCircuit(t *testing.T) {
+	defer Simulator.Reset()
+	defer Simulator.SetDecline("05", "Do not honor")
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{FailureThreshold: 2})
+	service := Simulator.NewService(valitor.WithCircuitBreaker(cb))
+
+	Simulator.SetDecline("91", "Issuer or switch inoperative")
+	Simulator.SetScenario(jsoncoretest.Decline)
+	for i := 0; i < 2; i++ {
+		service.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-breaker")
+	}
+	if state := cb.State(Simulator.URL + "/Payment/VirtualCardPayment"); state != helpers.CircuitOpen {
+		t.Log("Expected a retryable response code to count as a failure, got:", state)
+		t.Fatal()
+	}
+}
+
+func Test_Breaker_Bulkhead(t *testing.T) {
+	defer Simulator.Reset()
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{MaxConcurrent: 1})
+	service := Simulator.NewService(valitor.WithCircuitBreaker(cb))
+	Simulator.SetScenarioTimes("/Dcc", jsoncoretest.Timeout, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.Dcc(ctx, TestCardJSON, money.Money{Amount: 1500, Currency: money.ISK})
+	}()
+	for requestsTo("/Dcc") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	response := service.Dcc(context.Background(), TestCardJSON, money.Money{Amount: 1500, Currency: money.ISK})
+	cancel()
+	<-done
+	if !errors.Is(response.Err(), helpers.ErrBulkheadFull) || requestsTo("/Dcc") != 1 {
+		t.Log("Expected ErrBulkheadFull while the first call is running, got:", response.Err(), requestsTo("/Dcc"))
+		t.Fatal()
+	}
+
+	response = service.Dcc(context.Background(), TestCardJSON, money.Money{Amount: 1500, Currency: money.ISK})
+	if response.Err() != nil {
+		t.Log("Expected the slot to be free again, got:", response.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Breaker_CanceledCallsDoNotCount(t *testing.T) {
+	defer Simulator.Reset()
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	service := Simulator.NewService(valitor.WithCircuitBreaker(cb))
+	amount := money.Money{Amount: 1500, Currency: money.ISK}
+	endpoint := Simulator.URL + "/Dcc"
+	canceled := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		Simulator.SetScenarioTimes("/Dcc", jsoncoretest.Timeout, 1)
+		service.Dcc(ctx, TestCardJSON, amount)
+	}
+
+	Simulator.SetScenarioFor("/Dcc", jsoncoretest.Unavailable)
+	service.Dcc(context.Background(), TestCardJSON, amount)
+	canceled()
+	Simulator.SetScenarioFor("/Dcc", jsoncoretest.Unavailable)
+	service.Dcc(context.Background(), TestCardJSON, amount)
+	if state := cb.State(endpoint); state != helpers.CircuitOpen {
+		t.Log("Expected a canceled call to leave the failures in a row alone, got:", state)
+		t.Fatal()
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	canceled()
+	if state := cb.State(endpoint); state != helpers.CircuitHalfOpen {
+		t.Log("Expected a canceled probe to leave the circuit half open, got:", state)
+		t.Fatal()
+	}
+	Simulator.SetScenarioFor("/Dcc", jsoncoretest.Approve)
+	if response := service.Dcc(context.Background(), TestCardJSON, amount); response.Err() != nil || cb.State(endpoint) != helpers.CircuitClosed {
+		t.Log("Expected the next probe to close the circuit, got:", response.Err(), cb.State(endpoint))
+		t.Fatal()
+	}
+}
+
+func Test_Breaker_MissingAPIKey(t *testing.T) {
+	defer Simulator.Reset()
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{FailureThreshold: 1})
+	service := Simulator.NewService(valitor.WithAPIKey(""), valitor.WithCircuitBreaker(cb))
+
+	for i := 0; i < 2; i++ {
+		service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "", "", nil, nil, nil)
+	}
+	if state := cb.State(Simulator.URL + "/Payment/CardPayment"); state != helpers.CircuitClosed {
+		t.Log("Expected calls that were never sent to keep the circuit closed, got:", state)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	cassette "github.com/opensourcez/go-valitor/cassette"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func Test_Cassette_RecordAndReplay(t *testing.T) {
+	defer Simulator.Reset()
+	path := filepath.Join(t.TempDir(), "valitorpay.json")
+
+	recorder, err := cassette.New(path, cassette.ReplayOrRecord)
+	if err != nil || recorder.Mode() != cassette.Record {
+		t.Log("Expected a recorder in Record mode, got:", recorder.Mode(), err)
+		t.Fatal()
+	}
+	service := Simulator.NewService(valitor.WithTransport(recorder))
+	recorded := service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-cassette", "", nil, nil, nil)
+	if recorded.Err() != nil {
+		t.Log("Could not record the payment:", recorded.Err())
+		t.Fatal()
+	}
+	if err := recorder.Save(); err != nil {
+		t.Log("Could not save the cassette:", err)
+		t.Fatal()
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{TestCardJSON.Number, `"749"`, jsoncoretest.APIKey} {
+		if strings.Contains(string(data), secret) {
+			t.Log("The cassette contains a secret:", secret)
+			t.Fatal()
+		}
+	}
+
+	sent := len(Simulator.Requests())
+	recorder, err = cassette.New(path, cassette.ReplayOrRecord)
+	if err != nil || recorder.Mode() != cassette.Replay {
+		t.Log("Expected a recorder in Replay mode, got:", recorder.Mode(), err)
+		t.Fatal()
+	}
+	service = Simulator.NewService(valitor.WithTransport(recorder))
+	replayed := service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-cassette", "", nil, nil, nil)
+	if replayed.Err() != nil || replayed.TransactionID != recorded.TransactionID || len(Simulator.Requests()) != sent {
+		t.Log("Expected the recorded response without a request, got:", replayed.Err(), replayed.TransactionID)
+		t.Fatal()
+	}
+
+	replayed = service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-cassette", "", nil, nil, nil)
+	if !errors.Is(replayed.Err(), cassette.ErrNoInteraction) {
+		t.Log("Expected ErrNoInteraction once the cassette is used up, got:", replayed.Err())
+		t.Fatal()
+	}
+}
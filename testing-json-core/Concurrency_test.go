@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	metrics "github.com/opensourcez/go-valitor/metrics"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func Test_Concurrency_AllOperations(t *testing.T) {
+	defer Simulator.Reset()
+	service := Simulator.NewService(
+		valitor.WithMetrics(metrics.New()),
+		valitor.WithRetryPolicy(helpers.DefaultRetryPolicy()),
+		valitor.WithCircuitBreaker(helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{MaxConcurrent: 8, MaxWait: helpers.DefaultTimeout})),
+	)
+
+	if err := jsoncoretest.RunConcurrently(service, 16); err != nil {
+		t.Log("Expected every operation to succeed, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_Concurrency_RotateAPIKey(t *testing.T) {
+	defer Simulator.Reset()
+	service := Simulator.NewService(valitor.WithAPIKey("old-key"))
+
+	response := service.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-rotate")
+	if !errors.Is(response.Err(), jsoncore.ErrUnauthorized) {
+		t.Log("Expected the old key to be rejected, got:", response.Err())
+		t.Fatal()
+	}
+
+	settings := service.Settings()
+	settings.APIKey = jsoncoretest.APIKey
+	service.SetSettings(settings)
+	response = service.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-rotate")
+	if response.Err() != nil {
+		t.Log("Expected the rotated key to be used, got:", response.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Concurrency_RefreshingCredentials(t *testing.T) {
+	defer Simulator.Reset()
+	var fetches int32
+	keys := []string{"expired-key", jsoncoretest.APIKey}
+	credentials := &jsoncore.RefreshingCredentials{
+		Fetch: func(ctx context.Context) (jsoncore.Credentials, error) {
+			n := atomic.AddInt32(&fetches, 1)
+			return jsoncore.Credentials{APIKey: keys[n-1]}, nil
+		},
+	}
+	service := Simulator.NewService(valitor.WithCredentialProvider(credentials))
+
+	response := service.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-refresh")
+	if !errors.Is(response.Err(), jsoncore.ErrUnauthorized) {
+		t.Log("Expected the expired key to be rejected, got:", response.Err())
+		t.Fatal()
+	}
+	response = service.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-refresh")
+	if response.Err() != nil || atomic.LoadInt32(&fetches) != 2 {
+		t.Log("Expected new credentials to be fetched after the 401, got:", response.Err(), atomic.LoadInt32(&fetches))
+		t.Fatal()
+	}
+}
+
+func Test_Concurrency_RefreshingCredentialsSlowFetch(t *testing.T) {
+	release := make(chan struct{})
+	var fetches int32
+	credentials := &jsoncore.RefreshingCredentials{
+		Fetch: func(ctx context.Context) (jsoncore.Credentials, error) {
+			atomic.AddInt32(&fetches, 1)
+			<-release
+			return jsoncore.Credentials{APIKey: jsoncoretest.APIKey}, nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := credentials.Credentials(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Log("Expected the caller to give up on a slow fetch, got:", err)
+		t.Fatal()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if creds, err := credentials.Credentials(context.Background()); err != nil || creds.APIKey != jsoncoretest.APIKey {
+				t.Error("Expected the fetched credentials, got:", err, creds.APIKey)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&fetches) != 1 {
+		t.Log("Expected concurrent callers to share one fetch, got:", atomic.LoadInt32(&fetches))
+		t.Fatal()
+	}
+}
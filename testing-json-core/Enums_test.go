@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func Test_Enums_Validate(t *testing.T) {
+	valid := []interface{ Validate() error }{
+		jsoncore.OperationSale,
+		jsoncore.TransactionTypeECommerceWithCvc,
+		jsoncore.SubsequentTransactionMerchantInitiatedRecurring,
+		jsoncore.InitiationReason(""),
+		jsoncore.InitiationReasonUnscheduled,
+		jsoncore.CardholderDeviceType(""),
+		jsoncore.CardholderDeviceTypeWWW,
+	}
+	for _, value := range valid {
+		if err := value.Validate(); err != nil {
+			t.Log("Expected", value, "to be valid, got:", err)
+			t.Fatal()
+		}
+	}
+
+	invalid := []interface{ Validate() error }{
+		jsoncore.Operation(""),
+		jsoncore.Operation("sale"),
+		jsoncore.TransactionType("ECommerceWithCVC"),
+		jsoncore.SubsequentTransactionType(""),
+		jsoncore.InitiationReason("Later"),
+		jsoncore.CardholderDeviceType("Phone"),
+	}
+	for _, value := range invalid {
+		if err := value.Validate(); !errors.Is(err, jsoncore.ErrInvalidValue) {
+			t.Log("Expected", value, "to fail with ErrInvalidValue, got:", err)
+			t.Fatal()
+		}
+	}
+}
+
+func Test_Enums_NotSent(t *testing.T) {
+	Simulator.Reset()
+	defer Simulator.Reset()
+	ctx := context.Background()
+
+	payment := TCSJSON.CardPayment(ctx, TestCardJSON, "Sael", jsoncore.TransactionTypeECommerceWithCvc, money.Money{Amount: 1500, Currency: money.ISK}, "ref-enum", "", nil, nil, nil)
+	if !errors.Is(payment.Err(), jsoncore.ErrInvalidValue) {
+		t.Log("Expected ErrInvalidValue for a misspelled operation, got:", payment.Err())
+		t.Fatal()
+	}
+
+	subsequent := &jsoncore.SubsequentTransactionData{SubsequentTransactionType: "MerchantInitiated"}
+	payment = TCSJSON.CardPayment(ctx, TestCardJSON, jsoncore.OperationSale, jsoncore.TransactionTypeECommerceWithCvc, money.Money{Amount: 1500, Currency: money.ISK}, "ref-enum", "", subsequent, nil, nil)
+	if !errors.Is(payment.Err(), jsoncore.ErrInvalidValue) {
+		t.Log("Expected ErrInvalidValue for an unknown subsequent transaction type, got:", payment.Err())
+		t.Fatal()
+	}
+
+	virtual := TCSJSON.VirtualCardPayment(ctx, TestCardJSON, "Sometimes", money.Money{Amount: 1500, Currency: money.ISK}, "ref-enum")
+	if !errors.Is(virtual.Err(), jsoncore.ErrInvalidValue) {
+		t.Log("Expected ErrInvalidValue for an unknown initiation reason, got:", virtual.Err())
+		t.Fatal()
+	}
+
+	card := TCSJSON.CreateVirtualCard(ctx, TestCardJSON, nil, jsoncore.SubsequentTransactionCardholderInitiatedCredentialOnFile, "", "")
+	if !errors.Is(card.Err(), jsoncore.ErrInvalidValue) {
+		t.Log("Expected ErrInvalidValue for a missing transaction type, got:", card.Err())
+		t.Fatal()
+	}
+
+	if len(Simulator.Requests()) != 0 {
+		t.Log("Expected nothing to be sent to valitor, got:", len(Simulator.Requests()))
+		t.Fatal()
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+const problemDetailsBody = `{
+	"type": "https://tools.ietf.org/html/rfc7231#section-6.5.1",
+	"title": "One or more validation errors occurred.",
+	"status": 400,
+	"traceId": "|1a2b3c4d.5e6f",
+	"errors": {"Amount": ["The field Amount must be between 1 and 2147483647."]}
+}`
+
+func newErrorServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func cardPaymentAgainst(server *httptest.Server) jsoncore.CardPaymentResponse {
+	service := valitor.NewValitorPayService("053128", "225", server.URL, valitor.WithAPIKey("test-key"))
+	return service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 100, Currency: money.ISK}, "ref-1", "", nil, nil, nil)
+}
+
+func Test_Response_ProblemDetails(t *testing.T) {
+	server := newErrorServer(http.StatusBadRequest, problemDetailsBody)
+	defer server.Close()
+
+	response := cardPaymentAgainst(server)
+	var problem *jsoncore.ProblemDetails
+	if !errors.As(response.Err(), &problem) {
+		t.Log("Expected ProblemDetails, got:", response.Err())
+		t.Fatal()
+	}
+	if len(problem.Errors["Amount"]) != 1 || problem.TraceID != "|1a2b3c4d.5e6f" {
+		t.Log("Problem details were not parsed:", problem)
+		t.Fatal()
+	}
+	if !errors.Is(response.Err(), jsoncore.ErrBadRequest) || response.Code != "400" || response.IsSuccess {
+		t.Log("Expected a failed response with code 400, got:", response)
+		t.Fatal()
+	}
+}
+
+func Test_Response_Unauthorized(t *testing.T) {
+	server := newErrorServer(http.StatusUnauthorized, "")
+	defer server.Close()
+
+	response := cardPaymentAgainst(server)
+	if !errors.Is(response.Err(), jsoncore.ErrUnauthorized) {
+		t.Log("Expected ErrUnauthorized, got:", response.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Response_Declined(t *testing.T) {
+	server := newErrorServer(http.StatusOK, `{"isSuccess": false, "responseCode": "51", "responseDescription": "Insufficient funds"}`)
+	defer server.Close()
+
+	response := cardPaymentAgainst(server)
+	var responseError *jsoncore.ResponseError
+	if !errors.As(response.Err(), &responseError) || !responseError.Category().CardholderFacing() {
+		t.Log("Expected a cardholder facing ResponseError, got:", response.Err())
+		t.Fatal()
+	}
+	if !errors.Is(response.Err(), jsoncore.ErrInsufficientFunds) {
+		t.Log("Expected ErrInsufficientFunds, got:", response.Err())
+		t.Fatal()
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+// debugLogger logs everything to buf as JSON.
+func debugLogger(buf *bytes.Buffer) valitor.Option {
+	return valitor.WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+// lastRecord returns the last record logged for a call, the request and response bodies come before it.
+func lastRecord(buf *bytes.Buffer) map[string]interface{} {
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	record := map[string]interface{}{}
+	json.Unmarshal([]byte(lines[len(lines)-1]), &record)
+	return record
+}
+
+// containsSecret reports whether secret is in logged on its own, so a CVC
+// is not found inside a timestamp, a port or a generated id.
+func containsSecret(logged, secret string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(secret) + `\b`).MatchString(logged)
+}
+
+func Test_Log_RedactsCardData(t *testing.T) {
+	defer Simulator.Reset()
+	var buf bytes.Buffer
+
+	Simulator.NewService(debugLogger(&buf)).CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-log", "", nil, nil, nil)
+
+	logged := buf.String()
+	if !strings.Contains(logged, "valitor request") || !strings.Contains(logged, "530425******2887") {
+		t.Log("Expected the request with a masked card number, got:", logged)
+		t.Fatal()
+	}
+	for _, secret := range []string{TestCardJSON.Number, "749", jsoncoretest.APIKey} {
+		if containsSecret(logged, secret) {
+			t.Log("The log contains a secret:", secret)
+			t.Log(logged)
+			t.Fatal()
+		}
+	}
+
+	record := lastRecord(&buf)
+	if record["operation"] != "CardPayment" || record["status"] != float64(200) || record["level"] != "INFO" || record["transaction_lifecycle_id"] == nil || record["duration"] == nil {
+		t.Log("Expected a structured record for the call, got:", record)
+		t.Fatal()
+	}
+}
+
+func Test_Log_Decline(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenario(jsoncoretest.Decline)
+	var buf bytes.Buffer
+
+	Simulator.NewService(debugLogger(&buf)).VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-log")
+
+	record := lastRecord(&buf)
+	if record["operation"] != "VirtualCardPayment" || record["level"] != "WARN" || record["error_code"] != "05" || record["error_category"] != "cardholder" {
+		t.Log("Expected a declined record, got:", record)
+		t.Fatal()
+	}
+}
+
+func Test_Log_RedactsCards(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(helpers.NewRedactingHandler(slog.NewJSONHandler(&buf, nil)))
+
+	card := *TestCardJSON
+	card.CardVerificationData.CardholderAuthenticationVerificationData = "cavv-secret"
+	notLuhn := "4111111111111112"
+	logger.Info("card", "card", card, "pointer", &card)
+	logger.Info("fields", "cardNumber", notLuhn, "cvc", 749, "Oryggisnumer", 813, "body", []byte(`{"cardNumber":"`+notLuhn+`","cvc":749}`))
+	logger.Info("unknown", "request", struct{ Number string }{card.Number})
+
+	logged := buf.String()
+	for _, secret := range []string{card.Number, "749", "813", notLuhn, "cavv-secret"} {
+		if containsSecret(logged, secret) {
+			t.Log("The log contains a secret:", secret, logged)
+			t.Fatal()
+		}
+	}
+	if !strings.Contains(logged, "530425******2887") || !strings.Contains(logged, "411111******1112") {
+		t.Log("Expected the card numbers to be masked, got:", logged)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	metrics "github.com/opensourcez/go-valitor/metrics"
+	money "github.com/opensourcez/go-valitor/money"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricValue returns the value of the counter, or the sample count of the histogram,
+// with the given name and labels.
+func metricValue(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Log("Could not gather metrics:", err)
+		t.Fatal()
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value != label.GetValue() {
+					continue metrics
+				}
+			}
+			if metric.GetHistogram() != nil {
+				return float64(metric.GetHistogram().GetSampleCount())
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func Test_Metrics_CardPayment(t *testing.T) {
+	defer Simulator.Reset()
+	collector := metrics.New()
+	registry := prometheus.NewRegistry()
+	if err := collector.Register(registry); err != nil {
+		t.Log("Could not register the collector:", err)
+		t.Fatal()
+	}
+	service := Simulator.NewService(valitor.WithMetrics(collector))
+	amount := money.Money{Amount: 1500, Currency: money.ISK}
+
+	service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", amount, "ref-metrics", "", nil, nil, nil)
+	Simulator.SetScenario(jsoncoretest.Decline)
+	service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", amount, "ref-metrics", "", nil, nil, nil)
+	Simulator.SetScenario(jsoncoretest.Unauthorized)
+	service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", amount, "ref-metrics", "", nil, nil, nil)
+
+	operation := map[string]string{"core": metrics.CoreJSON, "operation": "CardPayment"}
+	with := func(name, value string) map[string]string {
+		labels := map[string]string{name: value}
+		for k, v := range operation {
+			labels[k] = v
+		}
+		return labels
+	}
+	checks := []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"valitor_requests_total", with("outcome", "success"), 1},
+		{"valitor_requests_total", with("outcome", metrics.OutcomeValitorError), 1},
+		{"valitor_requests_total", with("outcome", metrics.OutcomeSystemError), 1},
+		{"valitor_errors_total", with("error_code", "05"), 1},
+		{"valitor_http_responses_total", with("status", "200"), 2},
+		{"valitor_http_responses_total", with("status", "401"), 1},
+		{"valitor_request_duration_seconds", operation, 3},
+	}
+	for _, check := range checks {
+		if got := metricValue(t, registry, check.name, check.labels); got != check.want {
+			t.Log("Expected", check.name, check.labels, "to be", check.want, "got:", got)
+			t.Fatal()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func newJSONProvider(t *testing.T) valitor.PaymentProvider {
+	provider, err := valitor.NewPaymentProvider(valitor.ProviderConfig{
+		Backend:         valitor.BackendJSON,
+		URL:             Simulator.URL,
+		AgreementNumber: "053128",
+		TerminalID:      "225",
+		APIKey:          jsoncoretest.APIKey,
+	})
+	if err != nil {
+		t.Log("Could not create the JSON provider:", err)
+		t.Fatal()
+	}
+	return provider
+}
+
+func Test_Provider_JSON(t *testing.T) {
+	defer Simulator.Reset()
+	ctx := context.Background()
+	provider := newJSONProvider(t)
+	card := &valitor.Card{Number: TestCardJSON.Number, ExpMonth: TestCardJSON.ExpMonth, ExpYear: TestCardJSON.ExpYear, CVC: TestCardJSON.CVC}
+
+	token, err := provider.Tokenize(ctx, card)
+	if err != nil || token == "" {
+		t.Log("Expected a virtual card, got:", err, token)
+		t.Fatal()
+	}
+	card.Token = token
+	if _, err := provider.LastFour(ctx, card); !errors.Is(err, valitor.ErrNotSupported) {
+		t.Log("Expected ErrNotSupported, got:", err)
+		t.Fatal()
+	}
+
+	payment := valitor.Payment{Amount: money.Money{Amount: 2500, Currency: money.ISK}, Reference: "ref-provider"}
+	authorization, err := provider.Authorize(ctx, card, payment)
+	if err != nil || authorization.TransactionLifecycleID == "" || authorization.Amount != payment.Amount {
+		t.Log("Expected the authorization to be approved, got:", err, authorization)
+		t.Fatal()
+	}
+	captured, err := provider.Capture(ctx, card, authorization)
+	if err != nil || captured.TransactionLifecycleID != authorization.TransactionLifecycleID {
+		t.Log("Expected the authorization to be captured in the same lifecycle, got:", err, captured)
+		t.Fatal()
+	}
+	if _, err := provider.Capture(ctx, card, authorization); err == nil {
+		t.Log("Expected capturing more than was authorized to fail")
+		t.Fatal()
+	}
+	if _, err := provider.Refund(ctx, card, captured, valitor.Payment{Amount: payment.Amount, Reference: "ref-provider-refund"}); err != nil {
+		t.Log("Expected the capture to be refunded, got:", err)
+		t.Fatal()
+	}
+
+	second, _ := provider.Authorize(ctx, card, payment)
+	if _, err := provider.Void(ctx, card, second); err != nil {
+		t.Log("Expected the authorization to be voided, got:", err)
+		t.Fatal()
+	}
+	if _, err := provider.Capture(ctx, card, second); err == nil {
+		t.Log("Expected a voided authorization not to be captured")
+		t.Fatal()
+	}
+
+	card.ExpMonth, card.ExpYear = 5, jsoncoretest.ExpYear()+1
+	if err := provider.UpdateExpiry(ctx, card); err != nil {
+		t.Log("Expected the expiration date to be updated, got:", err)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+var testRetryPolicy = &helpers.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+
+func requestsTo(path string) int {
+	count := 0
+	for _, request := range Simulator.Requests() {
+		if request.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+func Test_Retry_SafeOperation(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/Dcc", jsoncoretest.Unavailable, 2)
+	service := Simulator.NewService(valitor.WithRetryPolicy(testRetryPolicy))
+
+	response := service.Dcc(context.Background(), TestCardJSON, money.Money{Amount: 1500, Currency: money.ISK})
+	if response.Err() != nil || requestsTo("/Dcc") != 3 {
+		t.Log("Expected a DCC offer on the third attempt, got:", response.Err(), requestsTo("/Dcc"))
+		t.Fatal()
+	}
+}
+
+func Test_Retry_PaymentWithoutReference(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/Payment/CardPayment", jsoncoretest.Unavailable, 1)
+	service := Simulator.NewService(valitor.WithRetryPolicy(testRetryPolicy))
+
+	response := service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "", "", nil, nil, nil)
+	if response.Err() == nil || requestsTo("/Payment/CardPayment") != 1 {
+		t.Log("Expected a payment without a reference not to be retried, got:", response.Err(), requestsTo("/Payment/CardPayment"))
+		t.Fatal()
+	}
+}
+
+func Test_Retry_PaymentWithReference(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/Payment/CardPayment", jsoncoretest.Unavailable, 1)
+	service := Simulator.NewService(valitor.WithRetryPolicy(testRetryPolicy))
+
+	response := service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-retry", "", nil, nil, nil)
+	if response.Err() != nil || requestsTo("/Payment/CardPayment") != 2 {
+		t.Log("Expected a payment with a reference to be retried after a 503, got:", response.Err(), requestsTo("/Payment/CardPayment"))
+		t.Fatal()
+	}
+}
+
+func Test_Retry_PaymentTimeout(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/Payment/CardPayment", jsoncoretest.Timeout, 1)
+	Simulator.SetScenarioTimes("/Dcc", jsoncoretest.Timeout, 1)
+	service := Simulator.NewService(
+		valitor.WithRetryPolicy(testRetryPolicy),
+		valitor.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}),
+	)
+
+	payment := service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "", "", nil, nil, nil)
+	if !errors.Is(payment.Err(), helpers.ErrOutcomeUnknown) || requestsTo("/Payment/CardPayment") != 1 {
+		t.Log("Expected ErrOutcomeUnknown and no retry after a timeout without a reference, got:", payment.Err(), requestsTo("/Payment/CardPayment"))
+		t.Fatal()
+	}
+
+	offer := service.Dcc(context.Background(), TestCardJSON, money.Money{Amount: 1500, Currency: money.ISK})
+	if offer.Err() != nil || requestsTo("/Dcc") != 2 {
+		t.Log("Expected a DCC offer to be retried after a timeout, got:", offer.Err(), requestsTo("/Dcc"))
+		t.Fatal()
+	}
+}
+
+func Test_Retry_CreateVirtualCardKeepsLifecycleID(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/VirtualCard/CreateVirtualCard", jsoncoretest.Unavailable, 1)
+	service := Simulator.NewService(valitor.WithRetryPolicy(testRetryPolicy))
+
+	response := service.CreateVirtualCard(context.Background(), TestCardJSON, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "")
+	if response.Err() != nil || response.TransactionLifecycleID == "" || requestsTo("/VirtualCard/CreateVirtualCard") != 2 {
+		t.Log("Expected a virtual card and a lifecycle id after one retry, got:", response.Err(), response.TransactionLifecycleID)
+		t.Fatal()
+	}
+}
+
+func Test_Retry_KeyedPaymentTimeout(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/Payment/VirtualCardPayment", jsoncoretest.Timeout, 1)
+	Simulator.SetScenarioTimes("/Payment/Capture", jsoncoretest.Timeout, 1)
+	service := Simulator.NewService(
+		valitor.WithRetryPolicy(testRetryPolicy),
+		valitor.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}),
+	)
+
+	payment := service.VirtualCardAuthorization(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-retry")
+	if payment.Err() != nil || requestsTo("/Payment/VirtualCardPayment") != 2 {
+		t.Log("Expected a payment with a reference to be retried after a timeout, got:", payment.Err(), requestsTo("/Payment/VirtualCardPayment"))
+		t.Fatal()
+	}
+
+	capture := service.Capture(context.Background(), payment.TransactionID, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-retry-capture")
+	if capture.Err() != nil || requestsTo("/Payment/Capture") != 2 {
+		t.Log("Expected a capture to be retried after a timeout, got:", capture.Err(), requestsTo("/Payment/Capture"))
+		t.Fatal()
+	}
+}
+
+func Test_Retry_MissingAPIKey(t *testing.T) {
+	defer Simulator.Reset()
+	service := Simulator.NewService(valitor.WithAPIKey(""), valitor.WithRetryPolicy(testRetryPolicy))
+
+	payment := service.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "", "", nil, nil, nil)
+	if !errors.Is(payment.Err(), jsoncore.ErrMissingAPIKey) || errors.Is(payment.Err(), helpers.ErrOutcomeUnknown) || len(Simulator.Requests()) != 0 {
+		t.Log("Expected ErrMissingAPIKey without sending anything, got:", payment.Err(), len(Simulator.Requests()))
+		t.Fatal()
+	}
+}
+
+func Test_Retry_CardPaymentWithVerificationTimeout(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioTimes("/VirtualCard/CreateVirtualCard", jsoncoretest.Timeout, 1)
+	service := Simulator.NewService(
+		valitor.WithRetryPolicy(testRetryPolicy),
+		valitor.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}),
+	)
+
+	response := service.CardPaymentWithVerification(context.Background(), TestCardJSON, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "")
+	if !errors.Is(response.Err(), helpers.ErrOutcomeUnknown) || requestsTo("/VirtualCard/CreateVirtualCard") != 1 {
+		t.Log("Expected ErrOutcomeUnknown and no retry after a timeout, got:", response.Err(), requestsTo("/VirtualCard/CreateVirtualCard"))
+		t.Fatal()
+	}
+}
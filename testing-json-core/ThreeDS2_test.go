@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func testBrowserInfo() *jsoncore.BrowserInfo {
+	return &jsoncore.BrowserInfo{
+		AcceptHeader:        "text/html",
+		IPAddress:           "192.0.2.10",
+		JavaScriptEnabled:   true,
+		Language:            "is-IS",
+		UserAgent:           "Mozilla/5.0",
+		ColorDepth:          24,
+		ScreenHeight:        1080,
+		ScreenWidth:         1920,
+		ChallengeWindowSize: jsoncore.ChallengeWindowFullScreen,
+	}
+}
+
+func Test_ThreeDS2_Frictionless(t *testing.T) {
+	defer Simulator.Reset()
+	verification := testCardVerification()
+	verification.BrowserInfo = testBrowserInfo()
+
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification)
+	if response.Flow() != jsoncore.FlowFrictionless || response.DSTransID == "" || response.ECI == "" {
+		t.Log("Expected a frictionless verification, got:", response.Err(), response.Flow(), response.TransStatus)
+		t.Fatal()
+	}
+
+	data, err := response.VerificationData()
+	if err != nil || data.CardholderAuthenticationVerificationData != response.AuthenticationValue || data.ThreeDSServerTransID != response.ThreeDSServerTransID {
+		t.Log("Expected the verification data from the response, got:", err, data)
+		t.Fatal()
+	}
+	payment := TCSJSON.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 150000, Currency: money.ISK}, "ref-3ds2", "", nil, &data, nil)
+	if payment.Err() != nil {
+		t.Log("Expected the frictionless payment to be approved, got:", payment.Err())
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDS2_Challenge(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetChallenge(true)
+	verification := testCardVerification()
+	verification.BrowserInfo = testBrowserInfo()
+
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification)
+	if response.Flow() != jsoncore.FlowChallenge || response.Field("creq") == "" {
+		t.Log("Expected a challenge, got:", response.Err(), response.Flow(), response.VerificationFields)
+		t.Fatal()
+	}
+	if _, err := response.VerificationData(); !errors.Is(err, jsoncore.ErrMissingVerificationData) {
+		t.Log("Expected no verification data before the challenge, got:", err)
+		t.Fatal()
+	}
+
+	_, form, ok := Simulator.Authenticate(response.Field("threeDSSessionData"), true)
+	callback, err := jsoncore.ParseCardVerificationCallback(form)
+	if !ok || err != nil || callback.Data.ECI == "" || callback.Data.DSTransID != response.DSTransID {
+		t.Log("Expected the 3DS2 verification data, got:", ok, err, callback)
+		t.Fatal()
+	}
+	payment := TCSJSON.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 150000, Currency: money.ISK}, "ref-3ds2-challenge", "", nil, &callback.Data, nil)
+	if payment.Err() != nil {
+		t.Log("Expected the challenged payment to be approved, got:", payment.Err())
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDS2_ChallengeFailed(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetChallenge(true)
+	verification := testCardVerification()
+	verification.BrowserInfo = testBrowserInfo()
+
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification)
+	_, form, _ := Simulator.Authenticate(response.Field("threeDSSessionData"), false)
+	if _, err := jsoncore.ParseCardVerificationCallback(form); !errors.Is(err, jsoncore.ErrMissingVerificationData) {
+		t.Log("Expected ErrMissingVerificationData for transStatus N, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDS2_BrowserInfo(t *testing.T) {
+	info := testBrowserInfo()
+	info.ColorDepth = 23
+	if err := info.Validate(); err == nil {
+		t.Log("Expected a color depth of 23 to be rejected")
+		t.Fatal()
+	}
+	info = testBrowserInfo()
+	info.ChallengeWindowSize = "06"
+	if err := info.Validate(); err == nil {
+		t.Log("Expected a challenge window size of 06 to be rejected")
+		t.Fatal()
+	}
+
+	verification := testCardVerification()
+	verification.BrowserInfo = &jsoncore.BrowserInfo{AcceptHeader: "text/html"}
+	if response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification); response.SystemError == nil {
+		t.Log("Expected incomplete browser info to be rejected before it is sent")
+		t.Fatal()
+	}
+
+	form := url.Values{"browserScreenWidth": {"390"}, "browserScreenHeight": {"844"}, "browserColorDepth": {"32"}, "browserTZ": {"0"}}
+	r := httptest.NewRequest("POST", "/checkout", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "text/html")
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	r.Header.Set("Accept-Language", "is-IS,is;q=0.9,en;q=0.8")
+	info = jsoncore.BrowserInfoFromRequest(r)
+	if info.Language != "is-IS" || !info.JavaScriptEnabled || info.ScreenWidth != 390 || info.ColorDepth != 32 || info.IPAddress != "192.0.2.1" {
+		t.Log("Expected browser info from the request, got:", info)
+		t.Fatal()
+	}
+	if err := info.Validate(); err != nil {
+		t.Log("Expected the browser info to be valid, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDS2_MethodForm(t *testing.T) {
+	response := jsoncore.CardVerificationResponse{ThreeDSMethodURL: "https://acs.example/method", ThreeDSMethodData: "eyJ0aHJlZURTU2VydmVyVHJhbnNJRCI6IjEifQ"}
+	form, err := response.MethodForm()
+	if err != nil || !strings.Contains(form, `action="https://acs.example/method"`) || !strings.Contains(form, response.ThreeDSMethodData) {
+		t.Log("Expected a hidden method form, got:", err, form)
+		t.Fatal()
+	}
+}
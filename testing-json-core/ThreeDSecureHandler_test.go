@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func callbackServer(results chan<- jsoncore.CardVerificationResult) (*httptest.Server, *jsoncore.CardVerificationHandler) {
+	handler := jsoncore.NewCardVerificationHandler(func(w http.ResponseWriter, r *http.Request, result jsoncore.CardVerificationResult) {
+		results <- result
+		http.Redirect(w, r, "/checkout", http.StatusSeeOther)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/3ds/success", handler)
+	mux.Handle("/3ds/failed", handler)
+	return httptest.NewServer(mux), handler
+}
+
+func noRedirects(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func Test_ThreeDSecureHandler_Authenticated(t *testing.T) {
+	defer Simulator.Reset()
+	results := make(chan jsoncore.CardVerificationResult, 1)
+	server, handler := callbackServer(results)
+	defer server.Close()
+
+	verification := testCardVerification()
+	verification.AuthorizationSuccessURL = server.URL + "/3ds/success"
+	verification.AuthorizationFailedURL = server.URL + "/3ds/failed"
+	if err := handler.Expect(context.Background(), verification); err != nil {
+		t.Log("Expected the verification to be registered, got:", err)
+		t.Fatal()
+	}
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification)
+	callbackURL, form, _ := Simulator.Authenticate(response.MD(), true)
+
+	client := &http.Client{CheckRedirect: noRedirects}
+	answer, err := client.PostForm(callbackURL, form)
+	if err != nil || answer.StatusCode != http.StatusSeeOther {
+		t.Log("Expected OnResult to redirect, got:", err, answer)
+		t.Fatal()
+	}
+	answer.Body.Close()
+
+	result := <-results
+	if !result.Authenticated || result.MerchantData != "order-1001" || result.Data.CardholderAuthenticationVerificationData == "" {
+		t.Log("Expected an authenticated result, got:", result)
+		t.Fatal()
+	}
+	payment := TCSJSON.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 150000, Currency: money.ISK}, "ref-3ds-handler", "", nil, &result.Data, nil)
+	if payment.Err() != nil {
+		t.Log("Expected the verified payment to be approved, got:", payment.Err())
+		t.Fatal()
+	}
+
+	replay, err := client.PostForm(callbackURL, form)
+	if err != nil || replay.StatusCode != http.StatusBadRequest {
+		t.Log("Expected a replayed callback to be rejected, got:", err, replay)
+		t.Fatal()
+	}
+	replay.Body.Close()
+}
+
+func Test_ThreeDSecureHandler_Failed(t *testing.T) {
+	defer Simulator.Reset()
+	results := make(chan jsoncore.CardVerificationResult, 1)
+	server, handler := callbackServer(results)
+	defer server.Close()
+
+	verification := testCardVerification()
+	verification.AuthorizationSuccessURL = server.URL + "/3ds/success"
+	verification.AuthorizationFailedURL = server.URL + "/3ds/failed"
+	handler.Expect(context.Background(), verification)
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification)
+	callbackURL, form, _ := Simulator.Authenticate(response.MD(), false)
+
+	answer, err := (&http.Client{CheckRedirect: noRedirects}).PostForm(callbackURL, form)
+	if err != nil || answer.StatusCode != http.StatusSeeOther {
+		t.Log("Expected OnResult to redirect, got:", err, answer)
+		t.Fatal()
+	}
+	answer.Body.Close()
+
+	result := <-results
+	if result.Authenticated || result.MerchantData != "order-1001" {
+		t.Log("Expected a result that is not authenticated, got:", result)
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDSecureHandler_UnknownMerchantData(t *testing.T) {
+	results := make(chan jsoncore.CardVerificationResult, 1)
+	server, _ := callbackServer(results)
+	defer server.Close()
+
+	answer, err := http.PostForm(server.URL+"/3ds/success", url.Values{
+		"merchantData": {"order-forged"},
+		"cardholderAuthenticationVerificationData": {"forged"},
+	})
+	if err != nil || answer.StatusCode != http.StatusBadRequest || len(results) != 0 {
+		t.Log("Expected an unknown MerchantData to be rejected, got:", err, answer)
+		t.Fatal()
+	}
+	answer.Body.Close()
+
+	answer, err = http.Get(server.URL + "/3ds/success")
+	if err != nil || answer.StatusCode != http.StatusMethodNotAllowed {
+		t.Log("Expected GET to be rejected, got:", err, answer)
+		t.Fatal()
+	}
+	answer.Body.Close()
+}
+
+func Test_ThreeDSecureHandler_NotConfigured(t *testing.T) {
+	handler := &jsoncore.CardVerificationHandler{Pending: jsoncore.NewMemoryPendingVerifications()}
+	if err := handler.Expect(context.Background(), testCardVerification()); !errors.Is(err, jsoncore.ErrHandlerNotConfigured) {
+		t.Log("Expected a handler without OnResult to be rejected, got:", err)
+		t.Fatal()
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	answer, err := http.PostForm(server.URL, url.Values{"merchantData": {"order-1001"}})
+	if err != nil || answer.StatusCode != http.StatusInternalServerError {
+		t.Log("Expected a handler without OnResult to answer 500, got:", err, answer)
+		t.Fatal()
+	}
+	answer.Body.Close()
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func testCardVerification() *jsoncore.CardVerification {
+	return &jsoncore.CardVerification{
+		CardNumber:              TestCardJSON.Number,
+		ExpirationMonth:         TestCardJSON.ExpMonth,
+		ExpirationYear:          TestCardJSON.ExpYear,
+		CardholderDeviceType:    "WWW",
+		Amount:                  150000,
+		Currency:                "ISK",
+		AuthorizationSuccessURL: "https://shop.example/3ds/success",
+		AuthorizationFailedURL:  "https://shop.example/3ds/failed",
+		MerchantData:            "order-1001",
+	}
+}
+
+func Test_ThreeDSecure_Verify(t *testing.T) {
+	defer Simulator.Reset()
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), testCardVerification())
+	if response.Err() != nil || response.PostURL != Simulator.URL+"/acs" || response.MD() == "" || response.PaReq() == "" {
+		t.Log("Expected the ACS redirect data, got:", response.Err(), response.PostURL, response.VerificationFields)
+		t.Fatal()
+	}
+
+	form, err := response.RedirectForm()
+	if err != nil || !strings.Contains(form, `action="`+response.PostURL+`"`) || !strings.Contains(form, response.MD()) {
+		t.Log("Expected a form that posts to the ACS, got:", err, form)
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDSecure_MissingCallbackURL(t *testing.T) {
+	verification := testCardVerification()
+	verification.AuthorizationFailedURL = ""
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), verification)
+	if !errors.Is(response.SystemError, jsoncore.ErrMissingCallbackURL) {
+		t.Log("Expected ErrMissingCallbackURL, got:", response.SystemError)
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDSecure_Payment(t *testing.T) {
+	defer Simulator.Reset()
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), testCardVerification())
+	if response.Err() != nil {
+		t.Log("Expected the ACS redirect data, got:", response.Err())
+		t.Fatal()
+	}
+
+	callbackURL, form, ok := Simulator.Authenticate(response.MD(), true)
+	if !ok || callbackURL != "https://shop.example/3ds/success" {
+		t.Log("Expected the ACS to post to the success URL, got:", ok, callbackURL)
+		t.Fatal()
+	}
+	callback, err := jsoncore.ParseCardVerificationCallback(form)
+	if err != nil || callback.MerchantData != "order-1001" {
+		t.Log("Expected the verification data, got:", err, callback)
+		t.Fatal()
+	}
+
+	payment := TCSJSON.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 150000, Currency: money.ISK}, "ref-3ds", "", nil, &callback.Data, nil)
+	if payment.Err() != nil {
+		t.Log("Expected the verified payment to be approved, got:", payment.Err())
+		t.Fatal()
+	}
+
+	forged := callback.Data
+	forged.CardholderAuthenticationVerificationData = "forged"
+	payment = TCSJSON.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 150000, Currency: money.ISK}, "ref-3ds-forged", "", nil, &forged, nil)
+	if payment.Err() == nil {
+		t.Log("Expected a payment with forged verification data to fail")
+		t.Fatal()
+	}
+}
+
+func Test_ThreeDSecure_Failed(t *testing.T) {
+	defer Simulator.Reset()
+	response := TCSJSON.VerifyCardUsing3DSecure(context.Background(), testCardVerification())
+	callbackURL, form, ok := Simulator.Authenticate(response.MD(), false)
+	if !ok || callbackURL != "https://shop.example/3ds/failed" {
+		t.Log("Expected the ACS to post to the failed URL, got:", ok, callbackURL)
+		t.Fatal()
+	}
+
+	callback, err := jsoncore.ParseCardVerificationCallback(form)
+	if !errors.Is(err, jsoncore.ErrMissingVerificationData) || callback.MerchantData != "order-1001" {
+		t.Log("Expected ErrMissingVerificationData with the merchant data, got:", err, callback)
+		t.Fatal()
+	}
+
+	if _, _, ok := Simulator.Authenticate(response.MD(), true); ok {
+		t.Log("Expected the MD to be single use")
+		t.Fatal()
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	metrics "github.com/opensourcez/go-valitor/metrics"
+	money "github.com/opensourcez/go-valitor/money"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func Test_Trace_CardPayment(t *testing.T) {
+	defer Simulator.Reset()
+	exporter := tracetest.NewInMemoryExporter()
+
+	Simulator.NewService(valitor.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))), valitor.WithPropagator(propagation.TraceContext{})).CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-trace", "", nil, nil, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "valitor CardPayment" {
+		t.Log("Expected one CardPayment span, got:", spans)
+		t.Fatal()
+	}
+	attrs := spanAttributes(spans[0])
+	if attrs["valitor.operation"].AsString() != "CardPayment" ||
+		attrs["valitor.agreement_number"].AsString() != "053128" ||
+		attrs["valitor.terminal_id"].AsString() != "225" ||
+		attrs["valitor.currency"].AsString() != "ISK" ||
+		attrs["valitor.amount"].AsInt64() != 1500 ||
+		attrs["valitor.outcome"].AsString() != metrics.OutcomeSuccess {
+		t.Log("Unexpected span attributes:", spans[0].Attributes)
+		t.Fatal()
+	}
+
+	request, _ := Simulator.LastRequest("/Payment/CardPayment")
+	traceparent := request.Header.Get("traceparent")
+	if traceparent == "" || traceparent[3:35] != spans[0].SpanContext.TraceID().String() {
+		t.Log("Expected the trace context in the request, got:", traceparent)
+		t.Fatal()
+	}
+}
+
+func Test_Trace_Declined(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenario(jsoncoretest.Decline)
+	exporter := tracetest.NewInMemoryExporter()
+
+	Simulator.NewService(valitor.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))), valitor.WithPropagator(propagation.TraceContext{})).VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-trace")
+
+	spans := exporter.GetSpans()
+	attrs := spanAttributes(spans[0])
+	if spans[0].Status.Code != codes.Error || attrs["valitor.outcome"].AsString() != metrics.OutcomeValitorError || attrs["valitor.error_code"].AsString() != "05" {
+		t.Log("Expected a declined span, got:", spans[0].Status, spans[0].Attributes)
+		t.Fatal()
+	}
+}
+
+func Test_Trace_VirtualCardAuthorization(t *testing.T) {
+	defer Simulator.Reset()
+	exporter := tracetest.NewInMemoryExporter()
+	collector := metrics.New()
+	registry := prometheus.NewRegistry()
+	if err := collector.Register(registry); err != nil {
+		t.Log("Could not register the collector:", err)
+		t.Fatal()
+	}
+
+	Simulator.NewService(valitor.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))), valitor.WithMetrics(collector)).VirtualCardAuthorization(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-trace")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "valitor VirtualCardAuthorization" || spanAttributes(spans[0])["valitor.operation"].AsString() != "VirtualCardAuthorization" {
+		t.Log("Expected one VirtualCardAuthorization span, got:", spans)
+		t.Fatal()
+	}
+	labels := map[string]string{"core": metrics.CoreJSON, "operation": "VirtualCardAuthorization", "outcome": metrics.OutcomeSuccess}
+	if value := metricValue(t, registry, "valitor_requests_total", labels); value != 1 {
+		t.Log("Expected the authorization to be counted as VirtualCardAuthorization, got:", value)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func Test_Transaction_CaptureAndRefund(t *testing.T) {
+	defer Simulator.Reset()
+	ctx := context.Background()
+	amount := money.Money{Amount: 5000, Currency: money.ISK}
+
+	authorization := TCSJSON.VirtualCardAuthorization(ctx, TestCardJSON, "", amount, "ref-auth")
+	if authorization.Err() != nil {
+		t.Log("Expected the authorization to be approved, got:", authorization.Err())
+		t.Fatal()
+	}
+
+	capture := TCSJSON.Capture(ctx, authorization.TransactionID, "", money.Money{Amount: 3000, Currency: money.ISK}, "ref-capture")
+	if capture.Err() != nil || capture.TransactionLifecycleID != authorization.TransactionLifecycleID {
+		t.Log("Expected a partial capture in the same lifecycle, got:", capture.Err(), capture.TransactionLifecycleID)
+		t.Fatal()
+	}
+	if more := TCSJSON.Capture(ctx, "", authorization.TransactionLifecycleID, amount, "ref-capture-2"); more.Err() == nil {
+		t.Log("Expected capturing more than was authorized to fail")
+		t.Fatal()
+	}
+
+	refund := TCSJSON.Refund(ctx, "", authorization.TransactionLifecycleID, money.Money{Amount: 3000, Currency: money.ISK}, "ref-refund")
+	if refund.Err() != nil || refund.TransactionID == "" {
+		t.Log("Expected the captured amount to be refunded, got:", refund.Err())
+		t.Fatal()
+	}
+	if again := TCSJSON.Refund(ctx, "", authorization.TransactionLifecycleID, money.Money{Amount: 1, Currency: money.ISK}, "ref-refund-2"); again.Err() == nil {
+		t.Log("Expected refunding more than was captured to fail")
+		t.Fatal()
+	}
+}
+
+func Test_Transaction_Reversal(t *testing.T) {
+	defer Simulator.Reset()
+	ctx := context.Background()
+	amount := money.Money{Amount: 5000, Currency: money.ISK}
+
+	authorization := TCSJSON.VirtualCardAuthorization(ctx, TestCardJSON, "", amount, "ref-auth")
+	reversal := TCSJSON.Reversal(ctx, authorization.TransactionID, authorization.TransactionLifecycleID, amount, "ref-reversal")
+	if reversal.Err() != nil {
+		t.Log("Expected the authorization to be reversed, got:", reversal.Err())
+		t.Fatal()
+	}
+	if capture := TCSJSON.Capture(ctx, authorization.TransactionID, "", amount, "ref-capture"); capture.Err() == nil {
+		t.Log("Expected a reversed authorization not to be captured")
+		t.Fatal()
+	}
+
+	if missing := TCSJSON.Reversal(ctx, "", "", amount, ""); !errors.Is(missing.SystemError, jsoncore.ErrMissingTransaction) {
+		t.Log("Expected ErrMissingTransaction, got:", missing.SystemError)
+		t.Fatal()
+	}
+}
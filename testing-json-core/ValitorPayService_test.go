@@ -1,37 +1,194 @@
 package test
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
 	"testing"
+	"time"
 
 	valitor "github.com/opensourcez/go-valitor"
 	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	jsoncoretest "github.com/opensourcez/go-valitor/jsoncore/jsoncoretest"
+	money "github.com/opensourcez/go-valitor/money"
 )
 
 var TestCardJSON = &jsoncore.Card{
 	Number:        "5304259906522887",
-	ExpYear:       2022,
+	ExpYear:       jsoncoretest.ExpYear(),
 	ExpMonth:      11,
 	CVC:           "749",
 	VirtualNumber: "4999993986001010",
 }
-var TCSJSON = valitor.NewValitorPayService(
-	"053128",
-	"225",
-	"",
-)
+
+// Simulator answers every request made by TCSJSON, no request leaves the process.
+var Simulator *jsoncoretest.Server
+var TCSJSON *jsoncore.CompanyService
+
+func TestMain(m *testing.M) {
+	Simulator = jsoncoretest.NewServer()
+	TCSJSON = Simulator.NewService()
+	code := m.Run()
+	Simulator.Close()
+	os.Exit(code)
+}
 
 func Test_CompanyService_CreateAVirtualCard(t *testing.T) {
+	defer Simulator.Reset()
+
+	response := TCSJSON.CreateVirtualCard(context.Background(), TestCardJSON, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "")
+	if response.Err() != nil || response.VirtualCard == "" {
+		t.Log("Expected a virtual card, got:", response.Err(), response)
+		t.Fatal()
+	}
+
+	request, _ := Simulator.LastRequest("/VirtualCard/CreateVirtualCard")
+	if request.Header.Get("Authorization") != "APIKey "+jsoncoretest.APIKey || request.Header.Get("valitor-api-version") == "" {
+		t.Log("Expected the API key and version headers, got:", request.Header)
+		t.Fatal()
+	}
+}
+
+func Test_CompanyService_CallerLifecycleID(t *testing.T) {
+	defer Simulator.Reset()
+
+	created := TCSJSON.CreateVirtualCard(context.Background(), TestCardJSON, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "lifecycle-create")
+	verified := TCSJSON.CardPaymentWithVerification(context.Background(), TestCardJSON, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "lifecycle-verify")
+	if created.Err() != nil || verified.Err() != nil || created.TransactionLifecycleID != "lifecycle-create" || verified.TransactionLifecycleID != "lifecycle-verify" {
+		t.Log("Expected the given lifecycle ids, got:", created.Err(), verified.Err(), created.TransactionLifecycleID, verified.TransactionLifecycleID)
+		t.Fatal()
+	}
+
+	var sent []string
+	for _, request := range Simulator.Requests() {
+		var body jsoncore.VirtualCardRequest
+		json.Unmarshal(request.Body, &body)
+		sent = append(sent, body.TransactionLifecycleID)
+	}
+	if len(sent) != 2 || sent[0] != "lifecycle-create" || sent[1] != "lifecycle-verify" {
+		t.Log("Expected the given lifecycle ids to be sent, got:", sent)
+		t.Fatal()
+	}
+}
+
+func Test_CompanyService_UpdateAVirtualCardsExpirationDate(t *testing.T) {
+	defer Simulator.Reset()
+
+	response := TCSJSON.UpdateExpirationDate(context.Background(), &jsoncore.Card{VirtualNumber: TestCardJSON.VirtualNumber, ExpMonth: 5, ExpYear: jsoncoretest.ExpYear() + 1}, nil, "ECommerceWithCvc")
+	if response.Err() != nil {
+		t.Log("Expected the expiration date to be updated, got:", response.Err())
+		t.Fatal()
+	}
+
+	var sent jsoncore.VirtualCardExpirationUpdateRequest
+	request, _ := Simulator.LastRequest("/VirtualCard/UpdateExpirationDate")
+	json.Unmarshal(request.Body, &sent)
+	if sent.ExpirationMonth != 5 || sent.ExpirationYear != jsoncoretest.ExpYear()+1 {
+		t.Log("Expected the new expiration date, got:", sent.ExpirationMonth, sent.ExpirationYear)
+		t.Fatal()
+	}
+}
+
+func Test_CompanyService_CardPayment(t *testing.T) {
+	defer Simulator.Reset()
+
+	response := TCSJSON.CardPayment(context.Background(), TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-1", "", nil, nil, nil)
+	if response.Err() != nil || response.TransactionID == "" || response.ReferenceNumber != "ref-1" {
+		t.Log("Expected an approved payment, got:", response.Err(), response)
+		t.Fatal()
+	}
+}
 
-	cardVer := jsoncore.CardVerificationData{}
-	virtualCardResponse := TCSJSON.CreateVirtualCard(TestCardJSON, &cardVer, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "")
-	log.Println("Test output!")
-	log.Println(virtualCardResponse)
+func Test_CompanyService_VirtualCardPayment(t *testing.T) {
+	defer Simulator.Reset()
+
+	response := TCSJSON.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-2")
+	if response.Err() != nil || response.AuthorizationCode == "" {
+		t.Log("Expected an approved payment, got:", response.Err(), response)
+		t.Fatal()
+	}
+}
+
+func Test_CompanyService_Dcc(t *testing.T) {
+	defer Simulator.Reset()
+
+	response := TCSJSON.Dcc(context.Background(), TestCardJSON, money.Money{Amount: 10000, Currency: money.ISK})
+	if response.Err() != nil || response.OfferCurrency == "" || response.DccInformationEncryptedValue == "" {
+		t.Log("Expected a DCC offer, got:", response.Err(), response)
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_Decline(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenarioFor("/Payment/VirtualCardPayment", jsoncoretest.Decline)
+
+	response := TCSJSON.VirtualCardPayment(context.Background(), TestCardJSON, "", money.Money{Amount: 1500, Currency: money.ISK}, "ref-3")
+	if !errors.Is(response.Err(), jsoncore.ErrDeclined) || response.IsSuccess {
+		t.Log("Expected ErrDeclined, got:", response.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_Unauthorized(t *testing.T) {
+	service := Simulator.NewService(valitor.WithAPIKey("wrong-key"))
+
+	response := service.Dcc(context.Background(), TestCardJSON, money.Money{Amount: 10000, Currency: money.ISK})
+	if !errors.Is(response.Err(), jsoncore.ErrUnauthorized) {
+		t.Log("Expected ErrUnauthorized, got:", response.Err())
+		t.Fatal()
+	}
 }
 
-// func Test_CompanyService_UpdateAVirtualCardsExpirationDate(t *testing.T) {
+func Test_Simulator_ValidationError(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenario(jsoncoretest.ValidationError)
+
+	response := TCSJSON.CreateVirtualCard(context.Background(), TestCardJSON, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", "")
+	var problem *jsoncore.ProblemDetails
+	if !errors.As(response.Err(), &problem) || problem.Status != 400 || len(problem.Errors) == 0 {
+		t.Log("Expected ProblemDetails, got:", response.Err())
+		t.Fatal()
+	}
+}
 
-// 	VirtualCardExpirationUpdateResponse := TCSJSON.UpdateVirtualCardsExpirationDate(TestCardJSON, "ECommerceWithCvc")
-// 	log.Println("Test output!")
-// 	log.Println(VirtualCardExpirationUpdateResponse)
-// }
+func Test_Simulator_Timeout(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetScenario(jsoncoretest.Timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	response := TCSJSON.CardPayment(ctx, TestCardJSON, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-4", "", nil, nil, nil)
+	if !errors.Is(response.Err(), context.DeadlineExceeded) {
+		t.Log("Expected context.DeadlineExceeded, got:", response.Err())
+		t.Fatal()
+	}
+}
+
+func Test_CompanyService_NormalizedCardNumber(t *testing.T) {
+	defer Simulator.Reset()
+	ctx := context.Background()
+	spaced := *TestCardJSON
+	spaced.Number = "5304 2599-0652 2887"
+
+	if response := TCSJSON.CreateVirtualCard(ctx, &spaced, nil, "CardholderInitiatedCredentialOnFile", "ECommerceWithCvc", ""); response.Err() != nil {
+		t.Log("Expected CreateVirtualCard to send the normalized number, got:", response.Err())
+		t.Fatal()
+	}
+	if response := TCSJSON.CardPayment(ctx, &spaced, "Sale", "ECommerceWithCvc", money.Money{Amount: 1500, Currency: money.ISK}, "ref-normalized", "", nil, nil, nil); response.Err() != nil {
+		t.Log("Expected CardPayment to send the normalized number, got:", response.Err())
+		t.Fatal()
+	}
+
+	verification := testCardVerification()
+	verification.CardNumber = spaced.Number
+	if response := TCSJSON.VerifyCardUsing3DSecure(ctx, verification); response.Err() != nil {
+		t.Log("Expected VerifyCardUsing3DSecure to send the normalized number, got:", response.Err())
+		t.Fatal()
+	}
+	if verification.CardNumber != spaced.Number {
+		t.Log("Expected the caller's CardVerification to be left alone, got:", verification.CardNumber)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,48 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func Test_Breaker_ServiceUnavailable(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+	cb := helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{FailureThreshold: 2})
+	service := Simulator.NewService(
+		valitor.WithCircuitBreaker(cb),
+	)
+
+	// Cardholder errors say nothing about valitor.
+	Simulator.SetError("FaHeimild", xmlcoretest.CodeInvalidAmount, "Ógild upphæð")
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	if cb.State(Simulator.URL) != helpers.CircuitClosed {
+		t.Log("Expected the circuit to stay closed, got:", cb.State(Simulator.URL))
+		t.Fatal()
+	}
+
+	Simulator.SetStatus("FaHeimild", http.StatusServiceUnavailable)
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	Simulator.ClearError("FaHeimild")
+
+	response := service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	if !errors.Is(response.Err(), helpers.ErrCircuitOpen) {
+		t.Log("Expected ErrCircuitOpen, got:", response.Err())
+		t.Fatal()
+	}
+	var valitorError *xmlcore.ValitorError
+	if errors.As(response.Err(), &valitorError) {
+		t.Log("Expected no call to valitor, got:", valitorError)
+		t.Fatal()
+	}
+}
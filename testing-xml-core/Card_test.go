@@ -0,0 +1,75 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	card "github.com/opensourcez/go-valitor/card"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func Test_Card_Validation(t *testing.T) {
+	cases := []struct {
+		Card     xmlcore.Card
+		Expected error
+	}{
+		{xmlcore.Card{Number: "5304259906522888", ExpMonth: 11, ExpYear: xmlcoretest.ExpYear(), CVC: "749"}, card.ErrChecksum},
+		{xmlcore.Card{Number: "5304 2599 06", ExpMonth: 11, ExpYear: xmlcoretest.ExpYear(), CVC: "749"}, card.ErrInvalidLength},
+		{xmlcore.Card{Number: "53042599O6522887", ExpMonth: 11, ExpYear: xmlcoretest.ExpYear(), CVC: "749"}, card.ErrInvalidNumber},
+		{xmlcore.Card{Number: "5304259906522887", ExpMonth: 13, ExpYear: xmlcoretest.ExpYear(), CVC: "749"}, card.ErrInvalidMonth},
+		{xmlcore.Card{Number: "5304259906522887", ExpMonth: 11, ExpYear: 20, CVC: "749"}, card.ErrExpired},
+		{xmlcore.Card{Number: "5304259906522887", ExpMonth: 11, ExpYear: 2020, CVC: "749"}, card.ErrExpired},
+	}
+
+	for _, c := range cases {
+		response := CS.FaSyndarkortnumer(context.Background(), &c.Card)
+		if response.SystemError != c.Expected {
+			t.Log("Expected:", c.Expected, " got ", response.SystemError, " for ", c.Card)
+			t.Fatal()
+		}
+	}
+}
+
+func Test_Card_DetectBrand(t *testing.T) {
+	cases := map[string]card.Brand{
+		"4111111111111111":    card.Visa,
+		"5304259906522887":    card.Mastercard,
+		"2221000000000009":    card.Mastercard,
+		"378282246310005":     card.Amex,
+		"6759649826438453":    card.Maestro,
+		"6011111111111117":    card.Discover,
+		"3530111333300000":    card.JCB,
+		"30569309025904":      card.DinersClub,
+		"6200000000000005":    card.UnionPay,
+		"4111 1111 1111 1111": card.Visa,
+		"9999999999999999":    card.Unknown,
+	}
+
+	for number, expected := range cases {
+		if brand := card.DetectBrand(number); brand != expected {
+			t.Log("Expected:", expected, " got ", brand, " for ", number)
+			t.Fatal()
+		}
+	}
+}
+
+func Test_Card_ParseMMYY(t *testing.T) {
+	expiry, err := card.ParseMMYY("0524")
+	if err != nil || expiry.Month != 5 || expiry.Year != 2024 || expiry.MMYY() != "0524" {
+		t.Log("Expected May 2024, got:", expiry, err)
+		t.Fatal()
+	}
+	if _, err := card.ParseMMYY("524"); err != card.ErrInvalidExpiry {
+		t.Log("Expected ErrInvalidExpiry, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_Card_MissingYear(t *testing.T) {
+	if err := card.NewExpiry(11, 0).Validate(time.Now()); err != card.ErrExpirationYear {
+		t.Log("Expected:", card.ErrExpirationYear, " got ", err)
+		t.Fatal()
+	}
+}
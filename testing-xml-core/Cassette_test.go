@@ -0,0 +1,48 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	cassette "github.com/opensourcez/go-valitor/cassette"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func Test_Cassette_ScrubsEnvelopes(t *testing.T) {
+	defer Simulator.Reset()
+	path := filepath.Join(t.TempDir(), "fyrirtaekjagreidslur.json")
+
+	recorder, _ := cassette.New(path, cassette.Record)
+	card := &xmlcore.Card{Number: "5304259902386667", ExpYear: xmlcoretest.ExpYear(), ExpMonth: 11, CVC: "376"}
+	recorded := Simulator.NewService(valitor.WithTransport(recorder)).FaSyndarkortnumer(context.Background(), card)
+	if recorded.Err() != nil {
+		t.Log("Could not record FaSyndarkortnumer:", recorded.Err())
+		t.Fatal()
+	}
+	recorder.Save()
+
+	data, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{card.Number, ">376<", xmlcoretest.Password} {
+		if strings.Contains(string(data), secret) {
+			t.Log("The cassette contains a secret:", secret)
+			t.Fatal()
+		}
+	}
+	if !strings.Contains(string(data), helpers.MaskPAN(card.Number)) {
+		t.Log("Expected the masked card number in the cassette")
+		t.Fatal()
+	}
+
+	recorder, _ = cassette.New(path, cassette.Replay)
+	replayed := Simulator.NewService(valitor.WithTransport(recorder)).FaSyndarkortnumer(context.Background(), card)
+	if replayed.Err() != nil || replayed.VirtualNumber == "" {
+		t.Log("Expected the recorded virtual number, got:", replayed.Err(), replayed.VirtualNumber)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,44 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	metrics "github.com/opensourcez/go-valitor/metrics"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func Test_Concurrency_AllOperations(t *testing.T) {
+	defer Simulator.Reset()
+	service := Simulator.NewService(
+		valitor.WithMetrics(metrics.New()),
+		valitor.WithRetryPolicy(helpers.DefaultRetryPolicy()),
+		valitor.WithCircuitBreaker(helpers.NewCircuitBreaker(helpers.CircuitBreakerSettings{MaxConcurrent: 8, MaxWait: helpers.DefaultTimeout})),
+	)
+
+	if err := xmlcoretest.RunConcurrently(service, 16); err != nil {
+		t.Log("Expected every operation to succeed, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_Concurrency_SetSettings(t *testing.T) {
+	defer Simulator.Reset()
+	service := valitor.NewValitorService("wrong", "wrong", xmlcoretest.ContractNumber, xmlcoretest.ContractIdentidyNumber, xmlcoretest.PosID, Simulator.URL)
+
+	settings := service.Settings()
+	settings.Username = xmlcoretest.Username
+	if service.Settings().Username != "wrong" {
+		t.Log("Expected Settings to return a copy")
+		t.Fatal()
+	}
+	settings.Password = xmlcoretest.Password
+	service.SetSettings(settings)
+
+	if err := xmlcoretest.RunAllOperations(context.Background(), service); err != nil {
+		t.Log("Expected the new credentials to be used, got:", err)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func Test_Envelope_EscapesValues(t *testing.T) {
+	var received struct {
+		Username      string `xml:"Body>FaHeimild>Notandanafn"`
+		Password      string `xml:"Body>FaHeimild>Lykilord"`
+		VirtualNumber string `xml:"Body>FaHeimild>Syndarkortnumer"`
+		Amount        string `xml:"Body>FaHeimild>Upphaed"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &received); err != nil {
+			t.Log("Could not parse the envelope:", err)
+			t.Log("Envelope:", string(body))
+			t.Fail()
+		}
+	}))
+	defer server.Close()
+
+	service := valitor.NewValitorService("user<name>", "pass&word</Lykilord>", "053128", "5006830589", "225", server.URL)
+	card := &xmlcore.Card{VirtualNumber: "5999<Upphaed>1</Upphaed>"}
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+
+	if received.Username != "user<name>" || received.Password != "pass&word</Lykilord>" {
+		t.Log("Credentials were not escaped:", received.Username, received.Password)
+		t.Fatal()
+	}
+	if received.VirtualNumber != card.VirtualNumber || received.Amount != "100" {
+		t.Log("Card values were not escaped:", received.VirtualNumber, received.Amount)
+		t.Fatal()
+	}
+}
+
+func Test_Envelope_ExpiryIsMMYY(t *testing.T) {
+	var received struct {
+		Expiration    string `xml:"Body>FaSyndarkortnumer>Gildistimi"`
+		NewExpiration string `xml:"Body>UppfaeraGildistima>NyrGildistimi"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		xml.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	service := valitor.NewValitorService("user", "password", "053128", "5006830589", "225", server.URL)
+	year := xmlcoretest.ExpYear()
+	cases := map[string]xmlcore.Card{
+		fmt.Sprintf("05%02d", year%100): {Number: "5304259906522887", ExpMonth: 5, ExpYear: year % 100, CVC: "749", VirtualNumber: "5999993615731195"},
+		fmt.Sprintf("11%02d", year%100): {Number: "5304259906522887", ExpMonth: 11, ExpYear: year, CVC: "749", VirtualNumber: "5999993615731195"},
+	}
+	for expected, card := range cases {
+		card := card
+		service.FaSyndarkortnumer(context.Background(), &card)
+		service.UppfaeraGildistima(context.Background(), &card)
+		if received.Expiration != expected || received.NewExpiration != expected {
+			t.Log("Expected:", expected, " got ", received.Expiration, " and ", received.NewExpiration)
+			t.Fatal()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+)
+
+const insufficientFundsBody = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body>
+<FaHeimildResponse xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
+<FaHeimildResult>
+<Villunumer>31</Villunumer>
+<Villuskilabod>Ekki næg heimild</Villuskilabod>
+<VilluLogID>1234</VilluLogID>
+</FaHeimildResult>
+</FaHeimildResponse>
+</soap:Body>
+</soap:Envelope>`
+
+func Test_Response_ValitorError(t *testing.T) {
+	server := newFaultServer(http.StatusOK, insufficientFundsBody)
+	defer server.Close()
+
+	response := faHeimildAgainst(server)
+	if response.SystemError != nil {
+		t.Log("Unexpected system error:", response.SystemError)
+		t.Fatal()
+	}
+
+	err := response.Err()
+	if !errors.Is(err, &xmlcore.ValitorError{Code: 31}) {
+		t.Log("Expected the error to match code 31, got:", err)
+		t.Fatal()
+	}
+
+	var valitorError *xmlcore.ValitorError
+	if !errors.As(err, &valitorError) || valitorError.Message != "Ekki næg heimild" || valitorError.LogID != "1234" {
+		t.Log("Expected a ValitorError with the message and log id, got:", err)
+		t.Fatal()
+	}
+}
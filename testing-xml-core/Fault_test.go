@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+)
+
+const soapFaultBody = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body>
+<soap:Fault>
+<faultcode>soap:Server</faultcode>
+<faultstring>Server was unable to process request.</faultstring>
+<detail />
+</soap:Fault>
+</soap:Body>
+</soap:Envelope>`
+
+const wrongOperationBody = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+<soap:Body>
+<FaEndurgreittResponse xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
+<FaEndurgreittResult><Villunumer>0</Villunumer></FaEndurgreittResult>
+</FaEndurgreittResponse>
+</soap:Body>
+</soap:Envelope>`
+
+func newFaultServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func faHeimildAgainst(server *httptest.Server) xmlcore.FaHeimild {
+	service := valitor.NewValitorService("user", "password", "053128", "5006830589", "225", server.URL)
+	return service.FaHeimild(context.Background(), &xmlcore.Card{VirtualNumber: "5999993615731195"}, money.Money{Amount: 100, Currency: money.ISK})
+}
+
+func Test_Response_SOAPFault(t *testing.T) {
+	server := newFaultServer(http.StatusInternalServerError, soapFaultBody)
+	defer server.Close()
+
+	response := faHeimildAgainst(server)
+	var fault *xmlcore.SOAPFault
+	if !errors.As(response.SystemError, &fault) {
+		t.Log("Expected a SOAPFault, got:", response.SystemError)
+		t.Fatal()
+	}
+	if fault.Code != "soap:Server" || fault.HTTPStatus != http.StatusInternalServerError {
+		t.Log("Fault was not parsed:", fault.Code, fault.HTTPStatus)
+		t.Fatal()
+	}
+}
+
+func Test_Response_HTMLErrorPage(t *testing.T) {
+	server := newFaultServer(http.StatusBadGateway, "<html><body><h1>502 Bad Gateway</h1></body></html>")
+	defer server.Close()
+
+	response := faHeimildAgainst(server)
+	var httpError *xmlcore.HTTPError
+	if !errors.As(response.SystemError, &httpError) || httpError.StatusCode != http.StatusBadGateway {
+		t.Log("Expected an HTTPError with status 502, got:", response.SystemError)
+		t.Fatal()
+	}
+}
+
+func Test_Response_EmptyBody(t *testing.T) {
+	server := newFaultServer(http.StatusOK, "")
+	defer server.Close()
+
+	response := faHeimildAgainst(server)
+	if !errors.Is(response.SystemError, xmlcore.ErrEmptyResponse) {
+		t.Log("Expected ErrEmptyResponse, got:", response.SystemError)
+		t.Fatal()
+	}
+}
+
+func Test_Response_WrongOperation(t *testing.T) {
+	server := newFaultServer(http.StatusOK, wrongOperationBody)
+	defer server.Close()
+
+	response := faHeimildAgainst(server)
+	var unexpected *xmlcore.UnexpectedResponseError
+	if !errors.As(response.SystemError, &unexpected) || unexpected.Got != "FaEndurgreittResponse" {
+		t.Log("Expected an UnexpectedResponseError, got:", response.SystemError)
+		t.Fatal()
+	}
+}
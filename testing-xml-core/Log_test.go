@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+// debugLogger logs everything to buf as JSON.
+func debugLogger(buf *bytes.Buffer) valitor.Option {
+	return valitor.WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+// lastRecord returns the last record logged for a call, the request and response bodies come before it.
+func lastRecord(buf *bytes.Buffer) map[string]interface{} {
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	record := map[string]interface{}{}
+	json.Unmarshal([]byte(lines[len(lines)-1]), &record)
+	return record
+}
+
+// containsSecret reports whether secret is in logged on its own, so a CVC
+// is not found inside a timestamp, a port or a generated id.
+func containsSecret(logged, secret string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(secret) + `\b`).MatchString(logged)
+}
+
+func Test_Log_RedactsEnvelope(t *testing.T) {
+	defer Simulator.Reset()
+	var buf bytes.Buffer
+
+	card := &xmlcore.Card{Number: "5304259906522887", ExpYear: xmlcoretest.ExpYear(), ExpMonth: 11, CVC: "749"}
+	Simulator.NewService(debugLogger(&buf)).FaSyndarkortnumer(context.Background(), card)
+
+	logged := buf.String()
+	if !strings.Contains(logged, "530425******2887") || !strings.Contains(logged, "<Lykilord>"+helpers.Redacted+"</Lykilord>") {
+		t.Log("Expected a redacted envelope, got:", logged)
+		t.Fatal()
+	}
+	for _, secret := range []string{card.Number, "749", xmlcoretest.Password} {
+		if containsSecret(logged, secret) {
+			t.Log("The log contains a secret:", secret)
+			t.Fatal()
+		}
+	}
+
+	record := lastRecord(&buf)
+	if record["operation"] != "FaSyndarkortnumer" || record["status"] != float64(200) || record["level"] != "INFO" {
+		t.Log("Expected a structured record for the call, got:", record)
+		t.Fatal()
+	}
+}
+
+func Test_Log_ValitorError(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetError("FaHeimild", 30, "Synjun")
+	var buf bytes.Buffer
+
+	Simulator.NewService(debugLogger(&buf)).FaHeimild(context.Background(), &xmlcore.Card{VirtualNumber: "5999000000000000"}, money.Money{Amount: 100, Currency: money.ISK})
+
+	record := lastRecord(&buf)
+	if record["operation"] != "FaHeimild" || record["level"] != "WARN" || record["error_code"] != "30" || record["error_log_id"] == "" {
+		t.Log("Expected a record with the Villunumer and VilluLogID, got:", record)
+		t.Fatal()
+	}
+}
+
+func Test_Log_RedactsCards(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(helpers.NewRedactingHandler(slog.NewJSONHandler(&buf, nil)))
+
+	card := xmlcore.Card{Number: "5304259906522887", ExpYear: xmlcoretest.ExpYear(), ExpMonth: 11, CVC: "749"}
+	notLuhn := "4111111111111112"
+	logger.Info("card", "card", card, "pointer", &card)
+	logger.Info("fields", "Kortnumer", notLuhn, "Oryggisnumer", 749, "body", []byte("<Kortnumer>"+notLuhn+"</Kortnumer><Oryggisnumer>749</Oryggisnumer>"))
+
+	logged := buf.String()
+	for _, secret := range []string{card.Number, "749", notLuhn} {
+		if containsSecret(logged, secret) {
+			t.Log("The log contains a secret:", secret, logged)
+			t.Fatal()
+		}
+	}
+	if !strings.Contains(logged, "530425******2887") || !strings.Contains(logged, "411111******1112") || !strings.Contains(logged, fmt.Sprintf(`"expiry":"11%02d"`, card.ExpYear%100)) {
+		t.Log("Expected the card numbers to be masked and the expiry kept, got:", logged)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,48 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	metrics "github.com/opensourcez/go-valitor/metrics"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_Metrics_FaHeimild(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+	collector := metrics.New()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	service := Simulator.NewService(
+		valitor.WithMetrics(collector),
+	)
+
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	Simulator.SetError("FaHeimild", xmlcoretest.CodeInvalidCredentials, "Rangt lykilorð")
+	service.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+
+	count, err := testutil.GatherAndCount(registry, "valitor_requests_total", "valitor_errors_total", "valitor_request_duration_seconds")
+	if err != nil || count != 4 {
+		t.Log("Expected two outcomes, one error code and one histogram, got:", count, err)
+		t.Fatal()
+	}
+	expected := `
+# HELP valitor_errors_total Valitor errors and declines by error code.
+# TYPE valitor_errors_total counter
+valitor_errors_total{core="xml",error_code="1",operation="FaHeimild"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "valitor_errors_total"); err != nil {
+		t.Log("Expected the Villunumer as error_code:", err)
+		t.Fatal()
+	}
+	if n := testutil.CollectAndCount(collector, "valitor_retries_total"); n != 0 {
+		t.Log("Expected no retries, got:", n)
+		t.Fatal()
+	}
+}
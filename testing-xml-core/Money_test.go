@@ -0,0 +1,129 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	money "github.com/opensourcez/go-valitor/money"
+)
+
+func Test_Money_Parse(t *testing.T) {
+	cases := []struct {
+		major    string
+		currency string
+		expected money.Money
+		minor    int64
+		back     string
+	}{
+		{"100", "ISK", money.Money{Amount: 100, Currency: money.ISK}, 100, "100"},
+		{"12.50", "EUR", money.Money{Amount: 1250, Currency: money.EUR}, 1250, "12.50"},
+		{"12,50", "eur", money.Money{Amount: 1250, Currency: money.EUR}, 1250, "12.50"},
+		{"0.05", "EUR", money.Money{Amount: 5, Currency: money.EUR}, 5, "0.05"},
+		{"7.5", "EUR", money.Money{Amount: 750, Currency: money.EUR}, 750, "7.50"},
+		{"1.234", "IQD", money.Money{Amount: 1234, Currency: "IQD"}, 1234, "1.234"},
+		{"0,001", "IQD", money.Money{Amount: 1, Currency: "IQD"}, 1, "0.001"},
+		{" 42 ", "IQD", money.Money{Amount: 42000, Currency: "IQD"}, 42000, "42.000"},
+	}
+	for _, c := range cases {
+		m, err := money.Parse(c.major, c.currency)
+		if err != nil || m != c.expected {
+			t.Log("Expected", c.major, c.currency, "to parse as", c.expected, "got:", m, err)
+			t.Fatal()
+		}
+		if m.MinorUnits() != c.minor || m.Major() != c.back {
+			t.Log("Expected", c.minor, "minor units and", c.back, "major, got:", m.MinorUnits(), m.Major())
+			t.Fatal()
+		}
+		if err := m.Validate(); err != nil {
+			t.Log("Expected", m, "to be valid, got:", err)
+			t.Fatal()
+		}
+	}
+}
+
+func Test_Money_ParseRejected(t *testing.T) {
+	cases := []struct {
+		major    string
+		currency string
+		expected error
+	}{
+		{"100.5", "ISK", money.ErrInvalidAmount},
+		{"100,5", "ISK", money.ErrInvalidAmount},
+		{"12.505", "EUR", money.ErrInvalidAmount},
+		{"1.2345", "IQD", money.ErrInvalidAmount},
+		{"12.5.0", "EUR", money.ErrInvalidAmount},
+		{"abc", "EUR", money.ErrInvalidAmount},
+		{"0", "EUR", money.ErrAmountMissing},
+		{"-5", "EUR", money.ErrNegativeAmount},
+		{"10", "", money.ErrCurrencyMissing},
+	}
+	for _, c := range cases {
+		if _, err := money.Parse(c.major, c.currency); !errors.Is(err, c.expected) {
+			t.Log("Expected", c.major, c.currency, "to fail with", c.expected, "got:", err)
+			t.Fatal()
+		}
+	}
+
+	var unknown *money.UnknownCurrencyError
+	if _, err := money.Parse("10", "XYZ"); !errors.As(err, &unknown) || unknown.Code != "XYZ" {
+		t.Log("Expected an UnknownCurrencyError for XYZ, got:", err)
+		t.Fatal()
+	}
+	if _, err := money.New(100, "ABC"); !errors.As(err, &unknown) {
+		t.Log("Expected an UnknownCurrencyError for ABC, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_Money_Major(t *testing.T) {
+	cases := []struct {
+		money    money.Money
+		expected string
+	}{
+		{money.Money{Amount: 100, Currency: money.ISK}, "100"},
+		{money.Money{Amount: 1250, Currency: money.EUR}, "12.50"},
+		{money.Money{Amount: 5, Currency: money.EUR}, "0.05"},
+		{money.Money{Amount: -1250, Currency: money.EUR}, "-12.50"},
+		{money.Money{Amount: 1, Currency: "IQD"}, "0.001"},
+		{money.Money{Amount: 12345, Currency: "IQD"}, "12.345"},
+	}
+	for _, c := range cases {
+		if c.money.Major() != c.expected || c.money.MinorUnits() != c.money.Amount {
+			t.Log("Expected", c.money.Amount, c.money.Currency, "to be", c.expected, "got:", c.money.Major())
+			t.Fatal()
+		}
+	}
+	if s := (money.Money{Amount: 1250, Currency: money.EUR}).String(); s != "12.50 EUR" {
+		t.Log("Expected 12.50 EUR, got:", s)
+		t.Fatal()
+	}
+}
+
+func Test_Money_Validate(t *testing.T) {
+	cases := []struct {
+		money    money.Money
+		expected error
+	}{
+		{money.Money{Amount: 1, Currency: money.EUR}, nil},
+		{money.Money{Amount: 1, Currency: "IQD"}, nil},
+		{money.Money{Amount: 0, Currency: money.EUR}, money.ErrAmountMissing},
+		{money.Money{Amount: -1, Currency: money.ISK}, money.ErrNegativeAmount},
+		{money.Money{Amount: 1}, money.ErrCurrencyMissing},
+		{money.Money{Amount: 1, Currency: "eur"}, &money.UnknownCurrencyError{}},
+	}
+	for _, c := range cases {
+		err := c.money.Validate()
+		var unknown *money.UnknownCurrencyError
+		if _, ok := c.expected.(*money.UnknownCurrencyError); ok {
+			if !errors.As(err, &unknown) {
+				t.Log("Expected an UnknownCurrencyError for", c.money.Currency, "got:", err)
+				t.Fatal()
+			}
+			continue
+		}
+		if !errors.Is(err, c.expected) {
+			t.Log("Expected", c.money, "to fail with", c.expected, "got:", err)
+			t.Fatal()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func newXMLProvider(t *testing.T) valitor.PaymentProvider {
+	provider, err := valitor.NewPaymentProvider(valitor.ProviderConfig{
+		Backend:                valitor.BackendXML,
+		URL:                    Simulator.URL,
+		Username:               xmlcoretest.Username,
+		Password:               xmlcoretest.Password,
+		ContractNumber:         xmlcoretest.ContractNumber,
+		ContractIdentidyNumber: xmlcoretest.ContractIdentidyNumber,
+		PosID:                  xmlcoretest.PosID,
+	})
+	if err != nil {
+		t.Log("Could not create the XML provider:", err)
+		t.Fatal()
+	}
+	return provider
+}
+
+func Test_Provider_XML(t *testing.T) {
+	defer Simulator.Reset()
+	ctx := context.Background()
+	provider := newXMLProvider(t)
+	card := &valitor.Card{Number: "5304259909334470", ExpMonth: 11, ExpYear: xmlcoretest.ExpYear(), CVC: "813"}
+
+	token, err := provider.Tokenize(ctx, card)
+	if err != nil || token == "" {
+		t.Log("Expected a virtual number, got:", err, token)
+		t.Fatal()
+	}
+	card.Token = token
+	if lastFour, err := provider.LastFour(ctx, card); err != nil || lastFour != "4470" {
+		t.Log("Expected the last four digits of the card, got:", err, lastFour)
+		t.Fatal()
+	}
+
+	payment := valitor.Payment{Amount: money.Money{Amount: 2500, Currency: money.ISK}, Reference: "ref-provider"}
+	authorization, err := provider.Authorize(ctx, card, payment)
+	if err != nil || authorization.TransactionID == "" || authorization.Amount != payment.Amount {
+		t.Log("Expected the authorization to be approved, got:", err, authorization)
+		t.Fatal()
+	}
+	if _, err := provider.Capture(ctx, card, authorization); err != nil {
+		t.Log("Expected the authorization to be captured, got:", err)
+		t.Fatal()
+	}
+	if transaction, _ := Simulator.Transaction(authorization.TransactionID); !transaction.Captured {
+		t.Log("Expected the simulator to record the capture")
+		t.Fatal()
+	}
+	if _, err := provider.Capture(ctx, card, authorization); !errors.Is(err, &xmlcore.ValitorError{Code: xmlcoretest.CodeAlreadyInvalidated}) {
+		t.Log("Expected a second capture to fail, got:", err)
+		t.Fatal()
+	}
+
+	refund, err := provider.Refund(ctx, card, authorization, payment)
+	if err != nil || refund.TransactionID == "" {
+		t.Log("Expected the refund to be approved, got:", err, refund)
+		t.Fatal()
+	}
+
+	second, _ := provider.Authorize(ctx, card, payment)
+	if _, err := provider.Void(ctx, card, second); err != nil {
+		t.Log("Expected the authorization to be voided, got:", err)
+		t.Fatal()
+	}
+	if transaction, _ := Simulator.Transaction(second.TransactionID); !transaction.Invalidated {
+		t.Log("Expected the simulator to record the void")
+		t.Fatal()
+	}
+
+	card.ExpMonth, card.ExpYear = 5, xmlcoretest.ExpYear()+1
+	if err := provider.UpdateExpiry(ctx, card); err != nil {
+		t.Log("Expected the expiration date to be updated, got:", err)
+		t.Fatal()
+	}
+	if virtualCard, _ := Simulator.VirtualCard(token); virtualCard.Expiry.MMYY() != fmt.Sprintf("05%02d", card.ExpYear%100) {
+		t.Log("Expected the simulator to store the new expiration date, got:", virtualCard.Expiry.MMYY())
+		t.Fatal()
+	}
+}
+
+func Test_Provider_XMLUnknownToken(t *testing.T) {
+	provider := newXMLProvider(t)
+	_, err := provider.Authorize(context.Background(), &valitor.Card{Token: "5999000000000000", CVC: "813"}, valitor.Payment{Amount: money.Money{Amount: 100, Currency: money.ISK}})
+	if !errors.Is(err, &xmlcore.ValitorError{Code: xmlcoretest.CodeInvalidVirtualNumber}) {
+		t.Log("Expected the unknown virtual number to be rejected, got:", err)
+		t.Fatal()
+	}
+}
+
+func Test_Provider_UnknownBackend(t *testing.T) {
+	if _, err := valitor.NewPaymentProvider(valitor.ProviderConfig{Backend: "soap"}); !errors.Is(err, valitor.ErrUnknownBackend) {
+		t.Log("Expected ErrUnknownBackend, got:", err)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	valitor "github.com/opensourcez/go-valitor"
+	helpers "github.com/opensourcez/go-valitor/helpers"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+)
+
+// attemptCounter counts the requests sent through it.
+type attemptCounter struct {
+	attempts int32
+}
+
+func (c *attemptCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.attempts, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var testRetryPolicy = &helpers.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+// pointAt sends the requests of service to url instead of Simulator.
+func pointAt(service *xmlcore.CompanyService, url string) *xmlcore.CompanyService {
+	settings := service.Settings()
+	settings.URL = url
+	service.SetSettings(settings)
+	return service
+}
+
+func Test_Retry_ServiceUnavailable(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+	Simulator.SetStatus("FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri", http.StatusServiceUnavailable)
+	Simulator.SetStatus("FaHeimild", http.StatusServiceUnavailable)
+	unavailable := &xmlcore.HTTPError{}
+
+	counter := &attemptCounter{}
+	lastFour := Simulator.NewService(valitor.WithTransport(counter), valitor.WithRetryPolicy(testRetryPolicy)).FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(context.Background(), card)
+	if !errors.As(lastFour.Err(), &unavailable) || unavailable.StatusCode != http.StatusServiceUnavailable || counter.attempts != 3 {
+		t.Log("Expected a safe operation to be tried three times, got:", lastFour.Err(), counter.attempts)
+		t.Fatal()
+	}
+
+	counter = &attemptCounter{}
+	authorization := Simulator.NewService(valitor.WithTransport(counter), valitor.WithRetryPolicy(testRetryPolicy)).FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	if !errors.As(authorization.Err(), &unavailable) || counter.attempts != 1 {
+		t.Log("Expected FaHeimild not to be retried, got:", authorization.Err(), counter.attempts)
+		t.Fatal()
+	}
+}
+
+func Test_Retry_NotSent(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	counter := &attemptCounter{}
+	response := pointAt(Simulator.NewService(valitor.WithTransport(counter), valitor.WithRetryPolicy(testRetryPolicy)), closed.URL).FaHeimild(context.Background(), &xmlcore.Card{VirtualNumber: "5999000000000000"}, money.Money{Amount: 100, Currency: money.ISK})
+	if response.Err() == nil || errors.Is(response.Err(), helpers.ErrOutcomeUnknown) || counter.attempts != 3 {
+		t.Log("Expected FaHeimild to be retried when the connection is refused, got:", response.Err(), counter.attempts)
+		t.Fatal()
+	}
+}
+
+func Test_Retry_AuthorizationTimeout(t *testing.T) {
+	var attempts int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	service := pointAt(Simulator.NewService(valitor.WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}), valitor.WithRetryPolicy(testRetryPolicy)), slow.URL)
+	response := service.FaHeimild(context.Background(), &xmlcore.Card{VirtualNumber: "5999000000000000"}, money.Money{Amount: 100, Currency: money.ISK})
+	if !errors.Is(response.Err(), helpers.ErrOutcomeUnknown) || atomic.LoadInt32(&attempts) != 1 {
+		t.Log("Expected ErrOutcomeUnknown and no retry after a timeout, got:", response.Err(), attempts)
+		t.Fatal()
+	}
+}
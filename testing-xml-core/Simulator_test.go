@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+)
+
+func newSimulatorCard(t *testing.T) *xmlcore.Card {
+	card := &xmlcore.Card{Number: "5304259909334470", ExpYear: xmlcoretest.ExpYear(), ExpMonth: 11, CVC: "813"}
+	response := CS.FaSyndarkortnumer(context.Background(), card)
+	if response.Err() != nil {
+		t.Log("Could not get a virtual number:", response.Err())
+		t.Fatal()
+	}
+	card.VirtualNumber = response.VirtualNumber
+	return card
+}
+
+func Test_Simulator_InjectedError(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+	Simulator.SetError("FaHeimild", xmlcoretest.CodeInvalidCredentials, "Rangt lykilorð")
+
+	response := CS.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	var valitorError *xmlcore.ValitorError
+	if !errors.As(response.Err(), &valitorError) || !errors.Is(response.Err(), &xmlcore.ValitorError{Code: xmlcoretest.CodeInvalidCredentials}) {
+		t.Log("Expected CodeInvalidCredentials, got:", response.Err())
+		t.Fatal()
+	}
+	if valitorError.Message != "Rangt lykilorð" || valitorError.LogID == "" {
+		t.Log("Expected the injected message and a log id, got:", valitorError)
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_UnknownVirtualNumber(t *testing.T) {
+	response := CS.FaHeimild(context.Background(), &xmlcore.Card{VirtualNumber: "5999000000000000"}, money.Money{Amount: 100, Currency: money.ISK})
+	if !errors.Is(response.Err(), &xmlcore.ValitorError{Code: xmlcoretest.CodeInvalidVirtualNumber}) {
+		t.Log("Expected CodeInvalidVirtualNumber, got:", response.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_VoidTwice(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+
+	sale := CS.FaHeimild(context.Background(), card, money.Money{Amount: 100, Currency: money.ISK})
+	if sale.Err() != nil {
+		t.Log("Could not get Authorization:", sale.Err())
+		t.Fatal()
+	}
+	void := CS.FaOgildingu(context.Background(), card, money.ISK, sale.Receipt.TransactionID)
+	if void.Err() != nil || !void.Receipt.Invalidated {
+		t.Log("Expected an invalidated receipt, got:", void.Err(), void.Receipt)
+		t.Fatal()
+	}
+	if transaction, _ := Simulator.Transaction(sale.Receipt.TransactionID); !transaction.Invalidated {
+		t.Log("Expected the simulator to record the void")
+		t.Fatal()
+	}
+
+	void = CS.FaOgildingu(context.Background(), card, money.ISK, sale.Receipt.TransactionID)
+	if !errors.Is(void.Err(), &xmlcore.ValitorError{Code: xmlcoretest.CodeAlreadyInvalidated}) {
+		t.Log("Expected CodeAlreadyInvalidated, got:", void.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_CaptureOnce(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+
+	capture := CS.NotaAdeinsheimild(context.Background(), card, "000000000999")
+	if !errors.Is(capture.Err(), &xmlcore.ValitorError{Code: xmlcoretest.CodeAuthorizationNotFound}) {
+		t.Log("Expected CodeAuthorizationNotFound, got:", capture.Err())
+		t.Fatal()
+	}
+
+	authorization := CS.FaAdeinsHeimild(context.Background(), card, money.Money{Amount: 2500, Currency: money.ISK})
+	if authorization.Err() != nil || authorization.Receipt.Amount != "2500" {
+		t.Log("Could not get Authorization (without payment):", authorization.Err(), authorization.Receipt)
+		t.Fatal()
+	}
+	if capture = CS.NotaAdeinsheimild(context.Background(), card, authorization.Receipt.TransactionID); capture.Err() != nil {
+		t.Log("Could not use Authorization:", capture.Err())
+		t.Fatal()
+	}
+	capture = CS.NotaAdeinsheimild(context.Background(), card, authorization.Receipt.TransactionID)
+	if !errors.Is(capture.Err(), &xmlcore.ValitorError{Code: xmlcoretest.CodeAlreadyInvalidated}) {
+		t.Log("Expected the second capture to fail, got:", capture.Err())
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_UpdateExpiration(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+	card.ExpMonth, card.ExpYear = 5, xmlcoretest.ExpYear()+1
+
+	if response := CS.UppfaeraGildistima(context.Background(), card); response.Err() != nil {
+		t.Log("Could not update card expiration date:", response.Err())
+		t.Fatal()
+	}
+	if virtualCard, _ := Simulator.VirtualCard(card.VirtualNumber); virtualCard.Expiry.MMYY() != fmt.Sprintf("05%02d", card.ExpYear%100) {
+		t.Log("Expected the new expiration date, got:", virtualCard.Expiry.MMYY())
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_NormalizedCardNumber(t *testing.T) {
+	defer Simulator.Reset()
+	spaced := &xmlcore.Card{Number: "5304 2599-0933 4470", ExpYear: xmlcoretest.ExpYear(), ExpMonth: 11, CVC: "813"}
+	response := CS.FaSyndarkortnumer(context.Background(), spaced)
+	if response.Err() != nil {
+		t.Log("Expected the card number to be sent without spaces and dashes, got:", response.Err())
+		t.Fatal()
+	}
+	if virtualCard, _ := Simulator.VirtualCard(response.VirtualNumber); virtualCard.Number != "5304259909334470" {
+		t.Log("Expected the simulator to store the normalized number, got:", virtualCard.Number)
+		t.Fatal()
+	}
+}
+
+func Test_Simulator_DecimalAmount(t *testing.T) {
+	defer Simulator.Reset()
+	card := newSimulatorCard(t)
+	amount := money.Money{Amount: 1250, Currency: money.EUR}
+
+	authorization := CS.FaHeimild(context.Background(), card, amount)
+	if authorization.Err() != nil || authorization.Receipt.Amount != "12.50" {
+		t.Log("Expected the receipt to carry 12.50, got:", authorization.Err(), authorization.Receipt.Amount)
+		t.Fatal()
+	}
+	if received, err := authorization.Receipt.Money(amount.Currency.String()); err != nil || received != amount {
+		t.Log("Expected the receipt amount to parse as", amount, "got:", received, err)
+		t.Fatal()
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	valitor "github.com/opensourcez/go-valitor"
+	metrics "github.com/opensourcez/go-valitor/metrics"
+	money "github.com/opensourcez/go-valitor/money"
+	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type headerRecorder struct {
+	header http.Header
+}
+
+func (h *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.header = req.Header.Clone()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_Trace_FaHeimild(t *testing.T) {
+	defer Simulator.Reset()
+	Simulator.SetError("FaHeimild", 31, "Ekki næg innstæða")
+	exporter := tracetest.NewInMemoryExporter()
+	recorder := &headerRecorder{}
+
+	service := Simulator.NewService(
+		valitor.WithTransport(recorder),
+		valitor.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))),
+		valitor.WithPropagator(propagation.TraceContext{}),
+	)
+	service.FaHeimild(context.Background(), &xmlcore.Card{VirtualNumber: "5999000000000000"}, money.Money{Amount: 2500, Currency: money.ISK})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "valitor FaHeimild" || spans[0].Status.Code != codes.Error {
+		t.Log("Expected one failed FaHeimild span, got:", spans)
+		t.Fatal()
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["valitor.contract_number"].AsString() != xmlcoretest.ContractNumber ||
+		attrs["valitor.pos_id"].AsString() != xmlcoretest.PosID ||
+		attrs["valitor.amount"].AsInt64() != 2500 ||
+		attrs["valitor.outcome"].AsString() != metrics.OutcomeValitorError ||
+		attrs["valitor.error_code"].AsString() != "31" {
+		t.Log("Unexpected span attributes:", spans[0].Attributes)
+		t.Fatal()
+	}
+
+	traceparent := recorder.header.Get("traceparent")
+	if traceparent == "" || traceparent[3:35] != spans[0].SpanContext.TraceID().String() {
+		t.Log("Expected the trace context in the request, got:", traceparent)
+		t.Fatal()
+	}
+}
@@ -1,43 +1,41 @@
 package test
 
 import (
-	"log"
+	"context"
+	"os"
 	"testing"
 
-	valitor "github.com/opensourcez/go-valitor"
-	helpers "github.com/opensourcez/go-valitor/helpers"
+	money "github.com/opensourcez/go-valitor/money"
 	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	xmlcoretest "github.com/opensourcez/go-valitor/xmlcore/xmlcoretest"
 )
 
 var TestCard = &xmlcore.Card{
 	Number:   "5304259906522887",
-	ExpYear:  22,
+	ExpYear:  xmlcoretest.ExpYear(),
 	ExpMonth: 11,
 	CVC:      "749",
 	// 5999993615731195
 	VirtualNumber: "",
 }
-var CS = valitor.NewValitorService(
-	"Valitortestfyrirtgr",
-	"testadgfyrirgr2010",
-	"053128",
-	"5006830589",
-	"225",
-	"https://api.processing.uat.valitor.com/Fyrirtaekjagreidslur/Fyrirtaekjagreidslur.asmx",
-)
+
+// Simulator answers every request made by CS, no request leaves the process.
+var Simulator *xmlcoretest.Server
+var CS *xmlcore.CompanyService
+
+func TestMain(m *testing.M) {
+	Simulator = xmlcoretest.NewServer()
+	CS = Simulator.NewService()
+	code := m.Run()
+	Simulator.Close()
+	os.Exit(code)
+}
+
 var VCAuth xmlcore.FaHeimild
 var VCAuthWithoutPayment xmlcore.FaAdeinsHeimild
-var hasBeenInitialized bool
 
-func InitTestThings() {
-	if !hasBeenInitialized {
-		helpers.DebugMode = true
-		hasBeenInitialized = true
-	}
-}
 func Test_CompanyService_FaSyndarkortnumer(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.FaSyndarkortnumer(TestCard)
+	xmlResponse := CS.FaSyndarkortnumer(context.Background(), TestCard)
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not get a virtual number for card: "+TestCard.Number, " .. not running more tests ...")
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -48,7 +46,7 @@ func Test_CompanyService_FaSyndarkortnumer(t *testing.T) {
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -58,7 +56,7 @@ func Test_CompanyService_FaSyndarkortnumer(t *testing.T) {
 	// Break card number
 	FaultyCard.Number = ""
 	ExpectedErrorMessage := "Card Number missing"
-	errorResponse := CS.FaSyndarkortnumer(FaultyCard)
+	errorResponse := CS.FaSyndarkortnumer(context.Background(), FaultyCard)
 	if errorResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", errorResponse.SystemError)
 		t.Fatal()
@@ -70,18 +68,18 @@ func Test_CompanyService_FaSyndarkortnumer(t *testing.T) {
 	// break expiration
 	FaultyCard.ExpYear = 0
 	ExpectedErrorMessage = "Expiration Year missing"
-	errorResponse = CS.FaSyndarkortnumer(FaultyCard)
+	errorResponse = CS.FaSyndarkortnumer(context.Background(), FaultyCard)
 	if errorResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", errorResponse.SystemError)
 		t.Fatal()
 		return
 	}
 
-	FaultyCard.ExpYear = 22
+	FaultyCard.ExpYear = xmlcoretest.ExpYear()
 
 	FaultyCard.ExpMonth = 0
 	ExpectedErrorMessage = "Expiration Month missing"
-	errorResponse = CS.FaSyndarkortnumer(FaultyCard)
+	errorResponse = CS.FaSyndarkortnumer(context.Background(), FaultyCard)
 	if errorResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", errorResponse.SystemError)
 		t.Fatal()
@@ -90,20 +88,20 @@ func Test_CompanyService_FaSyndarkortnumer(t *testing.T) {
 
 	FaultyCard.ExpYear = 0
 	ExpectedErrorMessage = "Expiration Month and Year missing"
-	errorResponse = CS.FaSyndarkortnumer(FaultyCard)
+	errorResponse = CS.FaSyndarkortnumer(context.Background(), FaultyCard)
 	if errorResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", errorResponse.SystemError)
 		t.Fatal()
 		return
 	}
 
-	FaultyCard.ExpYear = 22
+	FaultyCard.ExpYear = xmlcoretest.ExpYear()
 	FaultyCard.ExpMonth = 11
 
 	// break CVC
 	FaultyCard.CVC = ""
 	ExpectedErrorMessage = "CVC missing"
-	errorResponse = CS.FaSyndarkortnumer(FaultyCard)
+	errorResponse = CS.FaSyndarkortnumer(context.Background(), FaultyCard)
 	if errorResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", errorResponse.SystemError)
 		t.Fatal()
@@ -125,8 +123,7 @@ func Test_CompanyService_FaSyndarkortnumer(t *testing.T) {
 }
 
 func CompanyService_FaHeimild(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.FaHeimild(TestCard, "100", "ISK")
+	xmlResponse := CS.FaHeimild(context.Background(), TestCard, money.Money{Amount: 100, Currency: money.ISK})
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not get Authorization: " + TestCard.VirtualNumber)
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -144,7 +141,7 @@ func CompanyService_FaHeimild(t *testing.T) {
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -152,7 +149,7 @@ func CompanyService_FaHeimild(t *testing.T) {
 	}
 	FaultyCard.VirtualNumber = ""
 	ExpectedErrorMessage := "Virtual Number missing"
-	newResponse := CS.FaHeimild(FaultyCard, "100", "ISK")
+	newResponse := CS.FaHeimild(context.Background(), FaultyCard, money.Money{Amount: 100, Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -161,7 +158,7 @@ func CompanyService_FaHeimild(t *testing.T) {
 
 	FaultyCard.VirtualNumber = TestCard.VirtualNumber
 	ExpectedErrorMessage = "Currency missing"
-	newResponse = CS.FaHeimild(FaultyCard, "100", "")
+	newResponse = CS.FaHeimild(context.Background(), FaultyCard, money.Money{Amount: 100})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -169,7 +166,7 @@ func CompanyService_FaHeimild(t *testing.T) {
 	}
 
 	ExpectedErrorMessage = "Amount missing"
-	newResponse = CS.FaHeimild(FaultyCard, "", "ISK")
+	newResponse = CS.FaHeimild(context.Background(), FaultyCard, money.Money{Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -179,8 +176,7 @@ func CompanyService_FaHeimild(t *testing.T) {
 }
 
 func CompanyService_FaEndurgreitt(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.FaEndurgreitt(TestCard, "100", "ISK")
+	xmlResponse := CS.FaEndurgreitt(context.Background(), TestCard, money.Money{Amount: 100, Currency: money.ISK})
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not FaEndurgreitt: "+TestCard.VirtualNumber, " error:", xmlResponse)
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -190,14 +186,14 @@ func CompanyService_FaEndurgreitt(t *testing.T) {
 	}
 	jsonReceipt, err := xmlResponse.Receipt.ToJSON()
 	if err != nil {
-
-		panic(err)
+		t.Log("Could not marshal the FaEndurgreitt receipt:", err)
+		t.Fatal()
 	}
-	log.Println("Got a FaEndurgreitt receipt:", string(jsonReceipt))
+	t.Log("Got a FaEndurgreitt receipt:", string(jsonReceipt))
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -205,7 +201,7 @@ func CompanyService_FaEndurgreitt(t *testing.T) {
 	}
 	FaultyCard.VirtualNumber = ""
 	ExpectedErrorMessage := "Virtual Number missing"
-	newResponse := CS.FaEndurgreitt(FaultyCard, "100", "ISK")
+	newResponse := CS.FaEndurgreitt(context.Background(), FaultyCard, money.Money{Amount: 100, Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -214,7 +210,7 @@ func CompanyService_FaEndurgreitt(t *testing.T) {
 
 	FaultyCard.VirtualNumber = TestCard.VirtualNumber
 	ExpectedErrorMessage = "Currency missing"
-	newResponse = CS.FaEndurgreitt(FaultyCard, "100", "")
+	newResponse = CS.FaEndurgreitt(context.Background(), FaultyCard, money.Money{Amount: 100})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -222,7 +218,7 @@ func CompanyService_FaEndurgreitt(t *testing.T) {
 	}
 
 	ExpectedErrorMessage = "Amount missing"
-	newResponse = CS.FaEndurgreitt(FaultyCard, "", "ISK")
+	newResponse = CS.FaEndurgreitt(context.Background(), FaultyCard, money.Money{Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -231,8 +227,7 @@ func CompanyService_FaEndurgreitt(t *testing.T) {
 }
 
 func CompanyService_FaOgildingu(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.FaOgildingu(TestCard, "ISK", VCAuth.Receipt.TransactionID)
+	xmlResponse := CS.FaOgildingu(context.Background(), TestCard, "ISK", VCAuth.Receipt.TransactionID)
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not invalidate authorization number: " + VCAuth.Receipt.TransactionID)
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -248,7 +243,7 @@ func CompanyService_FaOgildingu(t *testing.T) {
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -256,7 +251,7 @@ func CompanyService_FaOgildingu(t *testing.T) {
 	}
 	FaultyCard.VirtualNumber = ""
 	ExpectedErrorMessage := "Virtual Number missing"
-	newResponse := CS.FaOgildingu(FaultyCard, "100", "ISK")
+	newResponse := CS.FaOgildingu(context.Background(), FaultyCard, "100", "ISK")
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -265,7 +260,7 @@ func CompanyService_FaOgildingu(t *testing.T) {
 
 	FaultyCard.VirtualNumber = TestCard.VirtualNumber
 	ExpectedErrorMessage = "Currency missing"
-	newResponse = CS.FaOgildingu(FaultyCard, "", "randomnumber")
+	newResponse = CS.FaOgildingu(context.Background(), FaultyCard, "", "randomnumber")
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -273,7 +268,7 @@ func CompanyService_FaOgildingu(t *testing.T) {
 	}
 
 	ExpectedErrorMessage = "Authorization number missing"
-	newResponse = CS.FaOgildingu(FaultyCard, "ISK", "")
+	newResponse = CS.FaOgildingu(context.Background(), FaultyCard, "ISK", "")
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -282,10 +277,9 @@ func CompanyService_FaOgildingu(t *testing.T) {
 }
 
 func CompanyService_UppfaeraGildistima(t *testing.T) {
-	InitTestThings()
 	TestCard.ExpMonth = 12
-	TestCard.ExpYear = 30
-	xmlResponse := CS.UppfaeraGildistima(TestCard)
+	TestCard.ExpYear = xmlcoretest.ExpYear()
+	xmlResponse := CS.UppfaeraGildistima(context.Background(), TestCard)
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not update card expiration date")
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -297,7 +291,7 @@ func CompanyService_UppfaeraGildistima(t *testing.T) {
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -305,7 +299,7 @@ func CompanyService_UppfaeraGildistima(t *testing.T) {
 	}
 	FaultyCard.VirtualNumber = ""
 	ExpectedErrorMessage := "Virtual Number missing"
-	newResponse := CS.UppfaeraGildistima(FaultyCard)
+	newResponse := CS.UppfaeraGildistima(context.Background(), FaultyCard)
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -316,18 +310,18 @@ func CompanyService_UppfaeraGildistima(t *testing.T) {
 	// break expiration
 	FaultyCard.ExpYear = 0
 	ExpectedErrorMessage = "Expiration Year missing"
-	newResponse = CS.UppfaeraGildistima(FaultyCard)
+	newResponse = CS.UppfaeraGildistima(context.Background(), FaultyCard)
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
 		return
 	}
 
-	FaultyCard.ExpYear = 22
+	FaultyCard.ExpYear = xmlcoretest.ExpYear()
 
 	FaultyCard.ExpMonth = 0
 	ExpectedErrorMessage = "Expiration Month missing"
-	newResponse = CS.UppfaeraGildistima(FaultyCard)
+	newResponse = CS.UppfaeraGildistima(context.Background(), FaultyCard)
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -336,7 +330,7 @@ func CompanyService_UppfaeraGildistima(t *testing.T) {
 
 	FaultyCard.ExpYear = 0
 	ExpectedErrorMessage = "Expiration Month and Year missing"
-	newResponse = CS.UppfaeraGildistima(FaultyCard)
+	newResponse = CS.UppfaeraGildistima(context.Background(), FaultyCard)
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -346,8 +340,7 @@ func CompanyService_UppfaeraGildistima(t *testing.T) {
 }
 
 func CompanyService_FaAdeinsHeimild(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.FaAdeinsHeimild(TestCard, "100", "ISK")
+	xmlResponse := CS.FaAdeinsHeimild(context.Background(), TestCard, money.Money{Amount: 100, Currency: money.ISK})
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not get Authorization (without payment): " + TestCard.VirtualNumber)
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -365,7 +358,7 @@ func CompanyService_FaAdeinsHeimild(t *testing.T) {
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -373,7 +366,7 @@ func CompanyService_FaAdeinsHeimild(t *testing.T) {
 	}
 	FaultyCard.VirtualNumber = ""
 	ExpectedErrorMessage := "Virtual Number missing"
-	newResponse := CS.FaAdeinsHeimild(FaultyCard, "100", "ISK")
+	newResponse := CS.FaAdeinsHeimild(context.Background(), FaultyCard, money.Money{Amount: 100, Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -382,7 +375,7 @@ func CompanyService_FaAdeinsHeimild(t *testing.T) {
 
 	FaultyCard.VirtualNumber = TestCard.VirtualNumber
 	ExpectedErrorMessage = "Currency missing"
-	newResponse = CS.FaAdeinsHeimild(FaultyCard, "100", "")
+	newResponse = CS.FaAdeinsHeimild(context.Background(), FaultyCard, money.Money{Amount: 100})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -390,7 +383,7 @@ func CompanyService_FaAdeinsHeimild(t *testing.T) {
 	}
 
 	ExpectedErrorMessage = "Amount missing"
-	newResponse = CS.FaAdeinsHeimild(FaultyCard, "", "ISK")
+	newResponse = CS.FaAdeinsHeimild(context.Background(), FaultyCard, money.Money{Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -399,7 +392,7 @@ func CompanyService_FaAdeinsHeimild(t *testing.T) {
 
 	FaultyCard.CVC = ""
 	ExpectedErrorMessage = "CVC missing"
-	newResponse = CS.FaAdeinsHeimild(FaultyCard, "100", "ISK")
+	newResponse = CS.FaAdeinsHeimild(context.Background(), FaultyCard, money.Money{Amount: 100, Currency: money.ISK})
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -408,8 +401,7 @@ func CompanyService_FaAdeinsHeimild(t *testing.T) {
 }
 
 func CompanyService_NotaAdeinsheimild(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.NotaAdeinsheimild(TestCard, VCAuthWithoutPayment.Receipt.TransactionID)
+	xmlResponse := CS.NotaAdeinsheimild(context.Background(), TestCard, VCAuthWithoutPayment.Receipt.TransactionID)
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not use Authorization: " + TestCard.VirtualNumber)
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -422,7 +414,7 @@ func CompanyService_NotaAdeinsheimild(t *testing.T) {
 
 	FaultyCard := &xmlcore.Card{
 		Number:   "5304259906522887",
-		ExpYear:  22,
+		ExpYear:  xmlcoretest.ExpYear(),
 		ExpMonth: 11,
 		CVC:      "749",
 		// 5999993615731195
@@ -430,7 +422,7 @@ func CompanyService_NotaAdeinsheimild(t *testing.T) {
 	}
 	FaultyCard.VirtualNumber = ""
 	ExpectedErrorMessage := "Virtual Number missing"
-	newResponse := CS.NotaAdeinsheimild(FaultyCard, "randomnumber")
+	newResponse := CS.NotaAdeinsheimild(context.Background(), FaultyCard, "randomnumber")
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -439,7 +431,7 @@ func CompanyService_NotaAdeinsheimild(t *testing.T) {
 
 	FaultyCard.VirtualNumber = TestCard.VirtualNumber
 	ExpectedErrorMessage = "Authorization number missing"
-	newResponse = CS.NotaAdeinsheimild(FaultyCard, "")
+	newResponse = CS.NotaAdeinsheimild(context.Background(), FaultyCard, "")
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -448,7 +440,7 @@ func CompanyService_NotaAdeinsheimild(t *testing.T) {
 
 	FaultyCard.CVC = ""
 	ExpectedErrorMessage = "CVC missing"
-	newResponse = CS.NotaAdeinsheimild(FaultyCard, "randomnumber")
+	newResponse = CS.NotaAdeinsheimild(context.Background(), FaultyCard, "randomnumber")
 	if newResponse.SystemError.Error() != ExpectedErrorMessage {
 		t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 		t.Fatal()
@@ -458,8 +450,7 @@ func CompanyService_NotaAdeinsheimild(t *testing.T) {
 }
 
 func CompanyService_FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(t *testing.T) {
-	InitTestThings()
-	xmlResponse := CS.FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(TestCard)
+	xmlResponse := CS.FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(context.Background(), TestCard)
 	if xmlResponse.ErrorCode != 0 || xmlResponse.SystemError != nil {
 		t.Log("Could not get last four digits: " + TestCard.VirtualNumber)
 		t.Log("System Error:", xmlResponse.SystemError)
@@ -478,7 +469,7 @@ func CompanyService_FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(t *testing.T)
 
 		FaultyCard := &xmlcore.Card{
 			Number:   "5304259906522887",
-			ExpYear:  22,
+			ExpYear:  xmlcoretest.ExpYear(),
 			ExpMonth: 11,
 			CVC:      "749",
 			// 5999993615731195
@@ -486,7 +477,7 @@ func CompanyService_FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(t *testing.T)
 		}
 		FaultyCard.VirtualNumber = ""
 		ExpectedErrorMessage := "Virtual Number missing"
-		newResponse := CS.NotaAdeinsheimild(FaultyCard, "randomnumber")
+		newResponse := CS.NotaAdeinsheimild(context.Background(), FaultyCard, "randomnumber")
 		if newResponse.SystemError.Error() != ExpectedErrorMessage {
 			t.Log("Expected:", ExpectedErrorMessage, " got ", newResponse.SystemError)
 			t.Fatal()
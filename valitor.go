@@ -1,10 +1,146 @@
 package valitor
 
 import (
+	"log/slog"
+	"net/http"
+
+	helpers "github.com/opensourcez/go-valitor/helpers"
 	jsoncore "github.com/opensourcez/go-valitor/jsoncore"
+	metrics "github.com/opensourcez/go-valitor/metrics"
 	xmlcore "github.com/opensourcez/go-valitor/xmlcore"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Option ...
+// Options can be passed to NewValitorPayService and NewValitorService
+// to change how the service talks to Valitor.
+type Option func(*options)
+
+type options struct {
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	apiKey      string
+	credentials jsoncore.CredentialProvider
+	logger      *slog.Logger
+	tracer      trace.TracerProvider
+	propagator  propagation.TextMapPropagator
+	metrics     *metrics.Collector
+	retry       *helpers.RetryPolicy
+	breaker     *helpers.CircuitBreaker
+}
+
+// WithHTTPClient ...
+// Use your own *http.Client for every request made by the service.
+// This is where you set your own timeouts, proxies and so on.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithTransport ...
+// Use your own http.RoundTripper for every request made by the service.
+// If WithHTTPClient is also used the transport is set on a copy of that client.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithAPIKey ...
+// The ValitorPay API key, only used by NewValitorPayService.
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithCredentialProvider ...
+// Fetch the ValitorPay API key from a provider before every request,
+// only used by NewValitorPayService. Takes precedence over WithAPIKey.
+func WithCredentialProvider(provider jsoncore.CredentialProvider) Option {
+	return func(o *options) {
+		o.credentials = provider
+	}
+}
+
+// WithLogger ...
+// Log every call to valitor with l instead of helpers.Logger().
+// Card data and credentials are redacted before they reach l.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithTracerProvider ...
+// Create a span for every call to valitor with tp instead of the global otel TracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracer = tp
+	}
+}
+
+// WithPropagator ...
+// Send the trace context to valitor with p instead of the global otel propagator.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = p
+	}
+}
+
+// WithMetrics ...
+// Record every call to valitor in c. One collector can be shared by many services,
+// register it once on a prometheus.Registerer.
+func WithMetrics(c *metrics.Collector) Option {
+	return func(o *options) {
+		o.metrics = c
+	}
+}
+
+// WithRetryPolicy ...
+// Send failed calls again according to p, helpers.DefaultRetryPolicy() is a good start.
+// Payments without a ReferenceNumber or TransactionLifecycleID are never sent again
+// when valitor might have processed them, they fail with helpers.ErrOutcomeUnknown instead.
+func WithRetryPolicy(p *helpers.RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = p
+	}
+}
+
+// WithCircuitBreaker ...
+// Fail calls at once with helpers.ErrCircuitOpen while an endpoint keeps failing, and limit
+// the calls running against it. One circuit breaker can be shared by many services.
+func WithCircuitBreaker(cb *helpers.CircuitBreaker) Option {
+	return func(o *options) {
+		o.breaker = cb
+	}
+}
+
+func buildOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// client returns the *http.Client the service should use.
+func (o *options) client() *http.Client {
+	if o.transport == nil {
+		return o.httpClient
+	}
+
+	client := &http.Client{Timeout: helpers.DefaultTimeout}
+	if o.httpClient != nil {
+		copied := *o.httpClient
+		client = &copied
+	}
+	client.Transport = o.transport
+	return client
+}
+
 // NewValitorPayService ...
 // This payment service will use the new JSON api from Valitor
 // Documentation: https://uat.valitorpay.com
@@ -12,19 +148,31 @@ func NewValitorPayService(
 	agreementNumber string,
 	terminalID string,
 	url string,
+	opts ...Option,
 ) *jsoncore.CompanyService {
 
 	if url == "" {
 		// Setting the default url as the test url
 		url = "https://uat.valitorpay.com"
 	}
-	return &jsoncore.CompanyService{
-		Settings: &jsoncore.Settings{
-			AgreementNumber: agreementNumber,
-			TerminalID:      terminalID,
-			URL:             url,
-		},
+	o := buildOptions(opts)
+	cs := &jsoncore.CompanyService{
+		HTTPClient:     o.client(),
+		Logger:         o.logger,
+		TracerProvider: o.tracer,
+		Propagator:     o.propagator,
+		Metrics:        o.metrics,
+		Retry:          o.retry,
+		CircuitBreaker: o.breaker,
 	}
+	cs.SetSettings(jsoncore.Settings{
+		AgreementNumber: agreementNumber,
+		TerminalID:      terminalID,
+		URL:             url,
+		APIKey:          o.apiKey,
+		Credentials:     o.credentials,
+	})
+	return cs
 }
 
 // NewValitorService ...
@@ -37,19 +185,29 @@ func NewValitorService(
 	contractIdentidyNumber string,
 	posID string,
 	url string,
+	opts ...Option,
 ) *xmlcore.CompanyService {
 	if url == "" {
 		// Setting the default url as the test url
 		url = "	https://api.processing.uat.valitor.com/Fyrirtaekjagreidslur/Fyrirtaekjagreidslur.asmx"
 	}
-	return &xmlcore.CompanyService{
-		Settings: &xmlcore.Settings{
-			Username:               username,
-			Password:               password,
-			ContractNumber:         contractNumber,
-			ContractIdentidyNumber: contractIdentidyNumber,
-			PosID:                  posID,
-			URL:                    url,
-		},
+	o := buildOptions(opts)
+	cs := &xmlcore.CompanyService{
+		HTTPClient:     o.client(),
+		Logger:         o.logger,
+		TracerProvider: o.tracer,
+		Propagator:     o.propagator,
+		Metrics:        o.metrics,
+		Retry:          o.retry,
+		CircuitBreaker: o.breaker,
 	}
+	cs.SetSettings(xmlcore.Settings{
+		Username:               username,
+		Password:               password,
+		ContractNumber:         contractNumber,
+		ContractIdentidyNumber: contractIdentidyNumber,
+		PosID:                  posID,
+		URL:                    url,
+	})
+	return cs
 }
@@ -1,14 +1,20 @@
 package xmlcore
 
 import (
+	"context"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
-	"strconv"
-	"strings"
-	"sync"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
 
+	"github.com/opensourcez/go-valitor/card"
 	"github.com/opensourcez/go-valitor/helpers"
+	"github.com/opensourcez/go-valitor/metrics"
+	"github.com/opensourcez/go-valitor/money"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Card struct {
@@ -21,10 +27,52 @@ type Card struct {
 	// Specific to the newer JSON API
 }
 
+// NormalizedNumber returns the card number without spaces and dashes, this is what is sent to valitor.
+func (c *Card) NormalizedNumber() string {
+	return card.Normalize(c.Number)
+}
+
+// Brand returns the card brand detected from the card number.
+func (c *Card) Brand() card.Brand {
+	return card.DetectBrand(c.Number)
+}
+
+// Expiry returns the expiration date of the card, ExpYear can have two or four digits.
+func (c *Card) Expiry() card.Expiry {
+	return card.NewExpiry(c.ExpMonth, c.ExpYear)
+}
+
+// LogValue implements slog.LogValuer, the number is masked and the CVC is left out.
+func (c Card) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("number", helpers.MaskPAN(c.Number)),
+		slog.String("virtualNumber", c.VirtualNumber),
+		slog.String("expiry", c.Expiry().MMYY()),
+	)
+}
+
 // CompanyService ...
+// A CompanyService is safe for concurrent use by many goroutines. The exported
+// fields must not be changed after the first call, use SetSettings to rotate credentials.
 type CompanyService struct {
-	Settings *Settings
-	Mux      sync.RWMutex
+	settings atomic.Pointer[Settings]
+	// HTTPClient is used for every request made by the service.
+	// If it is nil helpers.DefaultClient is used.
+	HTTPClient *http.Client
+	// Logger receives one record per call to valitor, request and response bodies
+	// are logged at slog.LevelDebug. If it is nil helpers.Logger() is used.
+	// Card data and credentials are always redacted.
+	Logger *slog.Logger
+	// TracerProvider and Propagator are used to trace every call to valitor.
+	// If they are nil the global ones from otel are used.
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+	// Metrics records every call to valitor, nothing is recorded if it is nil.
+	Metrics *metrics.Collector
+	// Retry decides which failed calls are sent again, nothing is retried if it is nil.
+	Retry *helpers.RetryPolicy
+	// CircuitBreaker fails calls at once while valitor is down, every call is sent if it is nil.
+	CircuitBreaker *helpers.CircuitBreaker
 }
 
 // Settings ...
@@ -37,6 +85,25 @@ type Settings struct {
 	URL                    string
 }
 
+// Settings returns a copy of the current settings.
+func (cs *CompanyService) Settings() Settings {
+	return *cs.snapshot()
+}
+
+// SetSettings replaces the settings in one step, for example to rotate the password.
+// Calls that have already started finish with the old settings.
+func (cs *CompanyService) SetSettings(settings Settings) {
+	cs.settings.Store(&settings)
+}
+
+// snapshot returns the settings for a single call, they must not be modified.
+func (cs *CompanyService) snapshot() *Settings {
+	if settings := cs.settings.Load(); settings != nil {
+		return settings
+	}
+	return &Settings{}
+}
+
 // VirtualNumber ...
 // Documentation: https://specs.valitor.is/CorporatePayments_ISL/Web_Services/#41-fasyndarkortnumer
 type FaSyndarkortnumer struct {
@@ -49,7 +116,7 @@ type FaSyndarkortnumer struct {
 
 // GetVirtualNumber ...
 // Documentation: https://specs.valitor.is/CorporatePayments_ISL/Web_Services/#41-fasyndarkortnumer
-func (cs *CompanyService) FaSyndarkortnumer(card *Card) (response FaSyndarkortnumer) {
+func (cs *CompanyService) FaSyndarkortnumer(ctx context.Context, card *Card) (response FaSyndarkortnumer) {
 	if err := checkCardExpirationDate(card); err != nil {
 		response.SystemError = err
 		return
@@ -65,34 +132,14 @@ func (cs *CompanyService) FaSyndarkortnumer(card *Card) (response FaSyndarkortnu
 		return
 	}
 
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<FaSyndarkortnumer xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<PosiID>` + cs.Settings.PosID + `</PosiID>
-		<Kortnumer>` + card.Number + `</Kortnumer>
-		<Gildistimi>` + strconv.Itoa(card.ExpMonth) + strconv.Itoa(card.ExpYear) + `</Gildistimi>
-		<Oryggisnumer>` + card.CVC + `</Oryggisnumer>
-		<Stillingar></Stillingar>
-		</FaSyndarkortnumer>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
-		response.SystemError = err
-		return
-	}
-
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "FaSyndarkortnumer", &faSyndarkortnumerRequest{
+		authentication: settings.authentication(),
+		PosID:          settings.PosID,
+		CardNumber:     card.NormalizedNumber(),
+		Expiration:     card.Expiry().MMYY(),
+		CVC:            card.CVC,
+	}, &response)
 	return
 }
 
@@ -108,49 +155,25 @@ type FaHeimild struct {
 
 // GetAuthorization ...
 // Documentation: https://specs.valitor.is/CorporatePayments_ISL/Web_Services/#42-faheimild
-func (cs *CompanyService) FaHeimild(card *Card, amount string, currency string) (response FaHeimild) {
+func (cs *CompanyService) FaHeimild(ctx context.Context, card *Card, amount money.Money) (response FaHeimild) {
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
 		return
 	}
 
-	if currency == "" {
-		response.SystemError = errors.New("Currency missing")
-		return
-	}
-	if amount == "" {
-		response.SystemError = errors.New("Amount missing")
-		return
-	}
-
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<FaHeimild xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<PosiID>` + cs.Settings.PosID + `</PosiID>
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<Upphaed>` + amount + `</Upphaed>
-		<Gjaldmidill>` + strings.ToUpper(currency) + `</Gjaldmidill>
-		<Stillingar></Stillingar>
-		</FaHeimild>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
+	if err := amount.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
 
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "FaHeimild", &faHeimildRequest{
+		authentication: settings.authentication(),
+		PosID:          settings.PosID,
+		VirtualNumber:  card.VirtualNumber,
+		Amount:         amount.Major(),
+		Currency:       amount.Currency.String(),
+	}, &response)
 	return
 }
 
@@ -162,7 +185,7 @@ type FaAdeinsHeimild struct {
 	Receipt      Receipt `xml:"Body>FaAdeinsheimildResponse>FaAdeinsheimildResult>Kvittun"`
 }
 
-func (cs *CompanyService) FaAdeinsHeimild(card *Card, amount string, currency string) (response FaAdeinsHeimild) {
+func (cs *CompanyService) FaAdeinsHeimild(ctx context.Context, card *Card, amount money.Money) (response FaAdeinsHeimild) {
 
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
@@ -173,44 +196,20 @@ func (cs *CompanyService) FaAdeinsHeimild(card *Card, amount string, currency st
 		response.SystemError = err
 		return
 	}
-	if currency == "" {
-		response.SystemError = errors.New("Currency missing")
-		return
-	}
-	if amount == "" {
-		response.SystemError = errors.New("Amount missing")
-		return
-	}
-
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<FaAdeinsheimild xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<PosiID>` + cs.Settings.PosID + `</PosiID>
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<Upphaed>` + amount + `</Upphaed>
-		<Gjaldmidill>` + strings.ToUpper(currency) + `</Gjaldmidill>
-		<Oryggisnumer>` + card.CVC + `</Oryggisnumer>
-		<Stillingar></Stillingar>
-		</FaAdeinsheimild>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
+	if err := amount.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
 
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "FaAdeinsheimild", &faAdeinsheimildRequest{
+		authentication: settings.authentication(),
+		PosID:          settings.PosID,
+		VirtualNumber:  card.VirtualNumber,
+		Amount:         amount.Major(),
+		Currency:       amount.Currency.String(),
+		CVC:            card.CVC,
+	}, &response)
 	return
 }
 
@@ -222,7 +221,7 @@ type NotaAdeinsheimild struct {
 	// Receipt      Receipt `xml:"Body>NotaAdeinsheimildResponse>NotaAdeinsheimildResult>Kvittun"`
 }
 
-func (cs *CompanyService) NotaAdeinsheimild(card *Card, authorizationNumber string) (response NotaAdeinsheimild) {
+func (cs *CompanyService) NotaAdeinsheimild(ctx context.Context, card *Card, authorizationNumber string) (response NotaAdeinsheimild) {
 
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
@@ -237,34 +236,14 @@ func (cs *CompanyService) NotaAdeinsheimild(card *Card, authorizationNumber stri
 		return
 	}
 
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<NotaAdeinsheimild xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<PosiID>` + cs.Settings.PosID + `</PosiID>
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<Oryggisnumer>` + card.CVC + `</Oryggisnumer>
-		<Faerslunumer>` + authorizationNumber + `</Faerslunumer>
-		<Stillingar></Stillingar>
-		</NotaAdeinsheimild>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
-		response.SystemError = err
-		return
-	}
-
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "NotaAdeinsheimild", &notaAdeinsheimildRequest{
+		authentication:      settings.authentication(),
+		PosID:               settings.PosID,
+		VirtualNumber:       card.VirtualNumber,
+		CVC:                 card.CVC,
+		AuthorizationNumber: authorizationNumber,
+	}, &response)
 	return
 }
 
@@ -276,50 +255,25 @@ type FaEndurgreitt struct {
 	Receipt      Receipt `xml:"Body>FaEndurgreittResponse>FaEndurgreittResult>Kvittun"`
 }
 
-func (cs *CompanyService) FaEndurgreitt(card *Card, amount string, currency string) (response FaEndurgreitt) {
+func (cs *CompanyService) FaEndurgreitt(ctx context.Context, card *Card, amount money.Money) (response FaEndurgreitt) {
 
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
 		return
 	}
 
-	if currency == "" {
-		response.SystemError = errors.New("Currency missing")
-		return
-	}
-
-	if amount == "" {
-		response.SystemError = errors.New("Amount missing")
-		return
-	}
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<FaEndurgreitt xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<PosiID>` + cs.Settings.PosID + `</PosiID>
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<Upphaed>` + amount + `</Upphaed>
-		<Gjaldmidill>` + strings.ToUpper(currency) + `</Gjaldmidill>
-		<Stillingar></Stillingar>
-		</FaEndurgreitt>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
+	if err := amount.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
-
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "FaEndurgreitt", &faEndurgreittRequest{
+		authentication: settings.authentication(),
+		PosID:          settings.PosID,
+		VirtualNumber:  card.VirtualNumber,
+		Amount:         amount.Major(),
+		Currency:       amount.Currency.String(),
+	}, &response)
 	return
 }
 
@@ -331,7 +285,7 @@ type FaOgildingu struct {
 	Receipt      Receipt `xml:"Body>FaOgildinguResponse>FaOgildinguResult>Kvittun"`
 }
 
-func (cs *CompanyService) FaOgildingu(card *Card, currency string, authorizationNumber string) (response FaOgildingu) {
+func (cs *CompanyService) FaOgildingu(ctx context.Context, card *Card, currency money.Currency, authorizationNumber string) (response FaOgildingu) {
 
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
@@ -341,39 +295,19 @@ func (cs *CompanyService) FaOgildingu(card *Card, currency string, authorization
 		response.SystemError = errors.New("Authorization number missing")
 		return
 	}
-	if currency == "" {
-		response.SystemError = errors.New("Currency missing")
-		return
-	}
-
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<FaOgildingu xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<Faerslunumer>` + authorizationNumber + `</Faerslunumer>
-		<PosiID>` + cs.Settings.PosID + `</PosiID>
-		<Gjaldmidill>` + strings.ToUpper(currency) + `</Gjaldmidill>
-		<Stillingar></Stillingar>
-		</FaOgildingu>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
+	if err := currency.Validate(); err != nil {
 		response.SystemError = err
 		return
 	}
 
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "FaOgildingu", &faOgildinguRequest{
+		authentication:      settings.authentication(),
+		VirtualNumber:       card.VirtualNumber,
+		AuthorizationNumber: authorizationNumber,
+		PosID:               settings.PosID,
+		Currency:            currency.String(),
+	}, &response)
 	return
 }
 
@@ -384,7 +318,7 @@ type UppfaeraGildistima struct {
 	ErrorLogID   string `xml:"Body>UppfaeraGildistimaResponse>UppfaeraGildistimaResult>VilluLogID"`
 }
 
-func (cs *CompanyService) UppfaeraGildistima(card *Card) (response UppfaeraGildistima) {
+func (cs *CompanyService) UppfaeraGildistima(ctx context.Context, card *Card) (response UppfaeraGildistima) {
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
 		return
@@ -393,32 +327,12 @@ func (cs *CompanyService) UppfaeraGildistima(card *Card) (response UppfaeraGildi
 		response.SystemError = err
 		return
 	}
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<UppfaeraGildistima xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<NyrGildistimi>` + strconv.Itoa(card.ExpMonth) + strconv.Itoa(card.ExpYear) + `</NyrGildistimi>
-		<Stillingar></Stillingar>
-		</UppfaeraGildistima>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
-		response.SystemError = err
-		return
-	}
-
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "UppfaeraGildistima", &uppfaeraGildistimaRequest{
+		authentication: settings.authentication(),
+		VirtualNumber:  card.VirtualNumber,
+		NewExpiration:  card.Expiry().MMYY(),
+	}, &response)
 	return
 }
 
@@ -430,36 +344,16 @@ type FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri struct {
 	Kortnumer    string `xml:"Body>FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeriResponse>FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeriResult>Kortnumer"`
 }
 
-func (cs *CompanyService) FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(card *Card) (response FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri) {
+func (cs *CompanyService) FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(ctx context.Context, card *Card) (response FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri) {
 	if err := checkCardForVirtualNumber(card); err != nil {
 		response.SystemError = err
 		return
 	}
-	body := `<?xml version="1.0" encoding="utf-8"?> 
-		<soap:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-		xmlns:xsd="http://www.w3.org/2001/XMLSchema" 
-	 	xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
-		<soap:Body>
-		<FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri xmlns="http://api.valitor.is/Fyrirtaekjagreidslur/">
-		<Notandanafn>` + cs.Settings.Username + `</Notandanafn>
-		<Lykilord>` + cs.Settings.Password + `</Lykilord>
-		<Samningsnumer>` + cs.Settings.ContractNumber + `</Samningsnumer>
-		<SamningsKennitala>` + cs.Settings.ContractIdentidyNumber + `</SamningsKennitala> 
-		<Syndarkortnumer>` + card.VirtualNumber + `</Syndarkortnumer>
-		<Stillingar></Stillingar>
-		</FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri>
-		</soap:Body> </soap:Envelope>`
-
-	resp, err := helpers.Send(cs.Settings.URL, "POST", body)
-	if err != nil {
-		response.SystemError = err
-		return
-	}
-	if err := xml.Unmarshal(resp, &response); err != nil {
-		response.SystemError = err
-		return
-	}
-
+	settings := cs.snapshot()
+	response.SystemError = cs.send(ctx, settings, "FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri", &faSidustuFjoraRequest{
+		authentication: settings.authentication(),
+		VirtualNumber:  card.VirtualNumber,
+	}, &response)
 	return
 }
 
@@ -473,7 +367,7 @@ type Receipt struct {
 	Date                  string `json:",omitempty" xml:"Dagsetning,omitempty"`
 	Time                  string `json:",omitempty" xml:"Timi,omitempty"`
 	MaskedPAN             string `json:",omitempty" xml:"Kortnumer,omitempty"`
-	Amount                int    `json:",omitempty" xml:"Upphaed,omitempty"`
+	Amount                string `json:",omitempty" xml:"Upphaed,omitempty"`
 	TransactionID         string `json:",omitempty" xml:"Faerslunumer,omitempty"`
 	ProcessorInfo         string `json:",omitempty" xml:"Faersluhirdir,omitempty"`
 	AuthorizationID       string `json:",omitempty" xml:"Heimildarnumer,omitempty"`
@@ -502,28 +396,23 @@ func (r *Receipt) ToJSON() (jsonAuth []byte, err error) {
 	return json.Marshal(r)
 }
 
+// Money parses Amount, the amount in major units as valitor sent it, for example 12.50.
+// currency is the currency of the payment, the receipt does not carry it.
+func (r *Receipt) Money(currency string) (money.Money, error) {
+	return money.Parse(r.Amount, currency)
+}
+
 func checkCardCVC(card *Card) error {
 	if card.CVC == "" {
 		return errors.New("CVC missing")
 	}
 	return nil
 }
-func checkCardNumber(card *Card) error {
-	if card.Number == "" {
-		return errors.New("Card Number missing")
-	}
-
-	return nil
+func checkCardNumber(c *Card) error {
+	return card.ValidateNumber(c.Number)
 }
-func checkCardExpirationDate(card *Card) error {
-	if card.ExpYear == 0 && card.ExpMonth == 0 {
-		return errors.New("Expiration Month and Year missing")
-	} else if card.ExpMonth == 0 {
-		return errors.New("Expiration Month missing")
-	} else if card.ExpYear == 0 {
-		return errors.New("Expiration Year missing")
-	}
-	return nil
+func checkCardExpirationDate(c *Card) error {
+	return c.Expiry().Validate(time.Now())
 }
 func checkCardForVirtualNumber(card *Card) error {
 	if card.VirtualNumber == "" {
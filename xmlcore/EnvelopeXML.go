@@ -0,0 +1,197 @@
+package xmlcore
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	"github.com/opensourcez/go-valitor/helpers"
+	"github.com/opensourcez/go-valitor/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// =====================================================
+//
+// SOAP ENVELOPES
+//
+// Every request is built from the structs below and marshalled
+// with encoding/xml, so all values are escaped properly.
+// The field order of each struct follows the order in the
+// documentation and must not be changed.
+//
+// Documentation: https://specs.valitor.is/CorporatePayments_ISL/Web_Services/
+//
+// =====================================================
+
+const (
+	soapNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	xsiNamespace  = "http://www.w3.org/2001/XMLSchema-instance"
+	xsdNamespace  = "http://www.w3.org/2001/XMLSchema"
+)
+
+type soapEnvelope struct {
+	XMLName xml.Name `xml:"soap:Envelope"`
+	XSI     string   `xml:"xmlns:xsi,attr"`
+	XSD     string   `xml:"xmlns:xsd,attr"`
+	SOAP    string   `xml:"xmlns:soap,attr"`
+	Body    soapBody `xml:"soap:Body"`
+}
+
+type soapBody struct {
+	// One of the *Request structs below
+	Operation interface{}
+}
+
+// authentication is the same for every operation
+type authentication struct {
+	Username               string `xml:"Notandanafn"`
+	Password               string `xml:"Lykilord"`
+	ContractNumber         string `xml:"Samningsnumer"`
+	ContractIdentidyNumber string `xml:"SamningsKennitala"`
+}
+
+type faSyndarkortnumerRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ FaSyndarkortnumer"`
+	authentication
+	PosID      string `xml:"PosiID"`
+	CardNumber string `xml:"Kortnumer"`
+	Expiration string `xml:"Gildistimi"`
+	CVC        string `xml:"Oryggisnumer"`
+	Options    string `xml:"Stillingar"`
+}
+
+type faHeimildRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ FaHeimild"`
+	authentication
+	PosID         string `xml:"PosiID"`
+	VirtualNumber string `xml:"Syndarkortnumer"`
+	Amount        string `xml:"Upphaed"`
+	Currency      string `xml:"Gjaldmidill"`
+	Options       string `xml:"Stillingar"`
+}
+
+type faAdeinsheimildRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ FaAdeinsheimild"`
+	authentication
+	PosID         string `xml:"PosiID"`
+	VirtualNumber string `xml:"Syndarkortnumer"`
+	Amount        string `xml:"Upphaed"`
+	Currency      string `xml:"Gjaldmidill"`
+	CVC           string `xml:"Oryggisnumer"`
+	Options       string `xml:"Stillingar"`
+}
+
+type notaAdeinsheimildRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ NotaAdeinsheimild"`
+	authentication
+	PosID               string `xml:"PosiID"`
+	VirtualNumber       string `xml:"Syndarkortnumer"`
+	CVC                 string `xml:"Oryggisnumer"`
+	AuthorizationNumber string `xml:"Faerslunumer"`
+	Options             string `xml:"Stillingar"`
+}
+
+type faEndurgreittRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ FaEndurgreitt"`
+	authentication
+	PosID         string `xml:"PosiID"`
+	VirtualNumber string `xml:"Syndarkortnumer"`
+	Amount        string `xml:"Upphaed"`
+	Currency      string `xml:"Gjaldmidill"`
+	Options       string `xml:"Stillingar"`
+}
+
+type faOgildinguRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ FaOgildingu"`
+	authentication
+	VirtualNumber       string `xml:"Syndarkortnumer"`
+	AuthorizationNumber string `xml:"Faerslunumer"`
+	PosID               string `xml:"PosiID"`
+	Currency            string `xml:"Gjaldmidill"`
+	Options             string `xml:"Stillingar"`
+}
+
+type uppfaeraGildistimaRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ UppfaeraGildistima"`
+	authentication
+	VirtualNumber string `xml:"Syndarkortnumer"`
+	NewExpiration string `xml:"NyrGildistimi"`
+	Options       string `xml:"Stillingar"`
+}
+
+type faSidustuFjoraRequest struct {
+	XMLName xml.Name `xml:"http://api.valitor.is/Fyrirtaekjagreidslur/ FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri"`
+	authentication
+	VirtualNumber string `xml:"Syndarkortnumer"`
+	Options       string `xml:"Stillingar"`
+}
+
+// buildEnvelope wraps an operation in a SOAP envelope.
+func buildEnvelope(operation interface{}) ([]byte, error) {
+	body, err := xml.Marshal(&soapEnvelope{
+		XSI:  xsiNamespace,
+		XSD:  xsdNamespace,
+		SOAP: soapNamespace,
+		Body: soapBody{Operation: operation},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func (s *Settings) authentication() authentication {
+	return authentication{
+		Username:               s.Username,
+		Password:               s.Password,
+		ContractNumber:         s.ContractNumber,
+		ContractIdentidyNumber: s.ContractIdentidyNumber,
+	}
+}
+
+// send posts the request to valitor and unmarshals the answer into response.
+// operation is the name of the SOAP operation, for example FaHeimild.
+func (cs *CompanyService) send(ctx context.Context, settings *Settings, operation string, request interface{}, response interface{}) error {
+	body, err := buildEnvelope(request)
+	if err != nil {
+		return err
+	}
+
+	ctx, span, header := cs.startSpan(ctx, settings, operation, request)
+	logger := helpers.ServiceLogger(cs.Logger).With("operation", operation)
+	idempotency := idempotency(operation)
+	restore := helpers.Snapshot(response)
+	start := time.Now()
+	var statusCode int
+	failure := cs.Retry.Do(ctx, idempotency, func() (failure helpers.Failure) {
+		restore()
+		release, breakerErr := cs.CircuitBreaker.Acquire(ctx, settings.URL)
+		if breakerErr != nil {
+			statusCode, err = 0, breakerErr
+			return helpers.FailureNone
+		}
+		defer func() { release(helpers.EndpointOutcome(ctx, failure)) }()
+
+		logger.DebugContext(ctx, "valitor request", "url", settings.URL, "body", body)
+		var resp []byte
+		resp, statusCode, err = helpers.Send(ctx, cs.HTTPClient, settings.URL, "POST", string(body), header)
+		if err != nil {
+			return helpers.ClassifyError(err)
+		}
+		logger.DebugContext(ctx, "valitor response", "status", statusCode, "body", resp)
+		err = parseResponse(operation, statusCode, resp, response)
+		return classify(err)
+	}, func(attempt int, failure helpers.Failure, wait time.Duration) {
+		helpers.LogRetry(ctx, logger, attempt, failure, wait, statusCode, err)
+		cs.Metrics.ObserveRetry(metrics.CoreXML, operation)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("valitor.attempt", attempt), attribute.String("valitor.failure", failure.String())))
+	})
+	err = helpers.OutcomeUnknown(err, idempotency, failure)
+	duration := time.Since(start)
+	details := errorDetails(response)
+	helpers.LogResult(ctx, logger, duration, statusCode, err, details)
+	helpers.Observe(cs.Metrics, metrics.CoreXML, operation, duration, statusCode, err, details)
+	helpers.EndSpan(span, statusCode, err, details)
+	return err
+}
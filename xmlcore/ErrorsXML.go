@@ -0,0 +1,130 @@
+package xmlcore
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/opensourcez/go-valitor/helpers"
+)
+
+// =====================================================
+//
+// VALITOR ERROR CODES
+//
+// Every response carries Villunumer, Villuskilabod and VilluLogID.
+// Err() on each response turns them into a *ValitorError which works
+// with errors.Is and errors.As, so there is no need to match on the
+// Icelandic messages. Match on the code with
+// errors.Is(err, &ValitorError{Code: code}).
+//
+// Villunumer codes are not mapped to sentinel errors or a
+// helpers.Category, the code tables in the documentation could
+// not be checked, so a ValitorError is never retried and is
+// logged with helpers.CategoryUnknown.
+//
+// Documentation: https://specs.valitor.is/CorporatePayments_ISL/Errors_Cardholder/
+// Documentation: https://specs.valitor.is/CorporatePayments_ISL/Web_Services/
+//
+// =====================================================
+
+// ValitorError ...
+// Valitor answered, but with a Villunumer other than 0.
+type ValitorError struct {
+	Operation string
+	Code      int
+	Message   string
+	LogID     string
+}
+
+func (e *ValitorError) Error() string {
+	msg := "Valitor error " + strconv.Itoa(e.Code)
+	if e.Operation != "" {
+		msg = e.Operation + ": " + msg
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.LogID != "" {
+		msg += " (log id: " + e.LogID + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is a *ValitorError with the same code.
+func (e *ValitorError) Is(target error) bool {
+	t, ok := target.(*ValitorError)
+	return ok && t.Code == e.Code
+}
+
+// errorDetails returns the Villunumer in response for logs, spans and metrics, or nil if there is none.
+func errorDetails(response interface{}) *helpers.ErrorDetails {
+	r, ok := response.(interface{ Err() error })
+	if !ok {
+		return nil
+	}
+	var valitorError *ValitorError
+	if !errors.As(r.Err(), &valitorError) {
+		return nil
+	}
+	return &helpers.ErrorDetails{
+		Code:    strconv.Itoa(valitorError.Code),
+		Message: valitorError.Message,
+		LogID:   valitorError.LogID,
+	}
+}
+
+// responseError is shared by the Err() methods on the responses.
+func responseError(operation string, systemError error, code int, message, logID string) error {
+	if systemError != nil {
+		return systemError
+	}
+	if code == 0 {
+		return nil
+	}
+	return &ValitorError{
+		Operation: operation,
+		Code:      code,
+		Message:   message,
+		LogID:     logID,
+	}
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r FaSyndarkortnumer) Err() error {
+	return responseError("FaSyndarkortnumer", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r FaHeimild) Err() error {
+	return responseError("FaHeimild", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r FaAdeinsHeimild) Err() error {
+	return responseError("FaAdeinsheimild", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r NotaAdeinsheimild) Err() error {
+	return responseError("NotaAdeinsheimild", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r FaEndurgreitt) Err() error {
+	return responseError("FaEndurgreitt", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r FaOgildingu) Err() error {
+	return responseError("FaOgildingu", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r UppfaeraGildistima) Err() error {
+	return responseError("UppfaeraGildistima", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
+
+// Err returns SystemError, or a *ValitorError if valitor returned an error code.
+func (r FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri) Err() error {
+	return responseError("FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri", r.SystemError, r.ErrorCode, r.ErrorMessage, r.ErrorLogID)
+}
@@ -0,0 +1,119 @@
+package xmlcore
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// =====================================================
+//
+// SOAP FAULTS AND TRANSPORT ERRORS
+//
+// These errors are placed in SystemError when valitor does not
+// answer with the response we asked for.
+//
+// =====================================================
+
+// ErrEmptyResponse is returned when valitor answers with an empty body.
+var ErrEmptyResponse = errors.New("Empty response from valitor")
+
+// maxErrorBody is how much of an unexpected body is kept in HTTPError.
+const maxErrorBody = 512
+
+// SOAPFault ...
+// Valitor answered with a soap:Fault, usually with HTTP status 500.
+type SOAPFault struct {
+	HTTPStatus int    `xml:"-"`
+	Code       string `xml:"faultcode"`
+	String     string `xml:"faultstring"`
+	Actor      string `xml:"faultactor"`
+	Detail     struct {
+		Content string `xml:",innerxml"`
+	} `xml:"detail"`
+}
+
+func (f *SOAPFault) Error() string {
+	return "SOAP Fault from valitor: " + f.Code + ": " + f.String
+}
+
+// HTTPError ...
+// Valitor answered with an HTTP status other than 200 and no soap:Fault.
+// Body holds the start of whatever came back, for example an HTML error page.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return "Unexpected HTTP status from valitor: " + strconv.Itoa(e.StatusCode)
+}
+
+// UnexpectedResponseError ...
+// Valitor answered with a SOAP body that is not the response to our operation.
+type UnexpectedResponseError struct {
+	Expected string
+	Got      string
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	if e.Got == "" {
+		return "Unexpected response from valitor, expected " + e.Expected + " but got nothing"
+	}
+	return "Unexpected response from valitor, expected " + e.Expected + " but got " + e.Got
+}
+
+type soapResponse struct {
+	Body struct {
+		Fault   *SOAPFault `xml:"Fault"`
+		Content []struct {
+			XMLName xml.Name
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+// parseResponse checks the answer to an operation and unmarshals it into response.
+func parseResponse(operation string, statusCode int, body []byte, response interface{}) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if statusCode != http.StatusOK {
+			return &HTTPError{StatusCode: statusCode}
+		}
+		return ErrEmptyResponse
+	}
+
+	var envelope soapResponse
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		if statusCode != http.StatusOK {
+			return newHTTPError(statusCode, body)
+		}
+		return err
+	}
+
+	if envelope.Body.Fault != nil {
+		envelope.Body.Fault.HTTPStatus = statusCode
+		return envelope.Body.Fault
+	}
+
+	if statusCode != http.StatusOK {
+		return newHTTPError(statusCode, body)
+	}
+
+	expected := operation + "Response"
+	if len(envelope.Body.Content) == 0 {
+		return &UnexpectedResponseError{Expected: expected}
+	}
+	if got := envelope.Body.Content[0].XMLName.Local; got != expected {
+		return &UnexpectedResponseError{Expected: expected, Got: got}
+	}
+
+	return xml.Unmarshal(body, response)
+}
+
+func newHTTPError(statusCode int, body []byte) *HTTPError {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return &HTTPError{StatusCode: statusCode, Body: string(body)}
+}
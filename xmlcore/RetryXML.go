@@ -0,0 +1,33 @@
+package xmlcore
+
+import (
+	"errors"
+
+	"github.com/opensourcez/go-valitor/helpers"
+)
+
+// safeOperations only read or update stored card data, sending them twice does no harm.
+// Every other operation moves money and is helpers.IdempotencyNone, the SOAP
+// service has no reference number that would let valitor spot a duplicate.
+var safeOperations = map[string]bool{
+	"FaSyndarkortnumer":                              true,
+	"UppfaeraGildistima":                             true,
+	"FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri": true,
+}
+
+func idempotency(operation string) helpers.Idempotency {
+	if safeOperations[operation] {
+		return helpers.IdempotencySafe
+	}
+	return helpers.IdempotencyNone
+}
+
+// classify tells the retry policy how an answer from valitor failed.
+// A soap:Fault or a Villunumer is an answer to the request and is never retried.
+func classify(err error) helpers.Failure {
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return helpers.ClassifyStatus(httpError.StatusCode)
+	}
+	return helpers.FailureNone
+}
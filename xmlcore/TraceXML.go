@@ -0,0 +1,55 @@
+package xmlcore
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opensourcez/go-valitor/helpers"
+	"github.com/opensourcez/go-valitor/money"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer, the spans are started by helpers.StartSpan.
+const instrumentationName = "github.com/opensourcez/go-valitor/xmlcore"
+
+// amountRequest is implemented by requests that carry an amount or a currency.
+type amountRequest interface {
+	amount() money.Money
+}
+
+func (r *faHeimildRequest) amount() money.Money {
+	amount, _ := money.Parse(r.Amount, r.Currency)
+	return amount
+}
+
+func (r *faAdeinsheimildRequest) amount() money.Money {
+	amount, _ := money.Parse(r.Amount, r.Currency)
+	return amount
+}
+
+func (r *faEndurgreittRequest) amount() money.Money {
+	amount, _ := money.Parse(r.Amount, r.Currency)
+	return amount
+}
+
+func (r *faOgildinguRequest) amount() money.Money {
+	return money.Money{Currency: money.Currency(r.Currency)}
+}
+
+// startSpan starts the client span for operation and returns the headers
+// carrying the trace context for the outbound request.
+func (cs *CompanyService) startSpan(ctx context.Context, settings *Settings, operation string, request interface{}) (context.Context, trace.Span, http.Header) {
+	var amount money.Money
+	if r, ok := request.(amountRequest); ok {
+		amount = r.amount()
+	}
+	ctx, span := helpers.StartSpan(ctx, helpers.Tracer(cs.TracerProvider, instrumentationName), operation, amount,
+		attribute.String("valitor.contract_number", settings.ContractNumber),
+		attribute.String("valitor.pos_id", settings.PosID),
+	)
+	header := http.Header{}
+	helpers.Propagator(cs.Propagator).Inject(ctx, propagation.HeaderCarrier(header))
+	return ctx, span, header
+}
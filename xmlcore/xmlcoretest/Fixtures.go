@@ -0,0 +1,92 @@
+package xmlcoretest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	valitor "github.com/opensourcez/go-valitor"
+	"github.com/opensourcez/go-valitor/money"
+	"github.com/opensourcez/go-valitor/xmlcore"
+)
+
+// ExpYear is three years from now, test cards that expire then stay valid while the tests run.
+func ExpYear() int {
+	return time.Now().AddDate(3, 0, 0).Year()
+}
+
+// NewService returns a service with the credentials the server accepts that sends every request to s.
+func (s *Server) NewService(opts ...valitor.Option) *xmlcore.CompanyService {
+	return valitor.NewValitorService(Username, Password, ContractNumber, ContractIdentidyNumber, PosID, s.URL, opts...)
+}
+
+// RunAllOperations calls every operation of service once with its own card and returns the first error.
+func RunAllOperations(ctx context.Context, service *xmlcore.CompanyService) error {
+	card := &xmlcore.Card{Number: "5304259909334470", ExpYear: ExpYear(), ExpMonth: 11, CVC: "813"}
+	virtual := service.FaSyndarkortnumer(ctx, card)
+	if virtual.Err() != nil {
+		return virtual.Err()
+	}
+	card.VirtualNumber = virtual.VirtualNumber
+	amount := money.Money{Amount: 100, Currency: money.ISK}
+
+	sale := service.FaHeimild(ctx, card, amount)
+	if sale.Err() != nil {
+		return sale.Err()
+	}
+	if void := service.FaOgildingu(ctx, card, money.ISK, sale.Receipt.TransactionID); void.Err() != nil {
+		return void.Err()
+	}
+	authorization := service.FaAdeinsHeimild(ctx, card, amount)
+	if authorization.Err() != nil {
+		return authorization.Err()
+	}
+	if capture := service.NotaAdeinsheimild(ctx, card, authorization.Receipt.TransactionID); capture.Err() != nil {
+		return capture.Err()
+	}
+	if refund := service.FaEndurgreitt(ctx, card, amount); refund.Err() != nil {
+		return refund.Err()
+	}
+	card.ExpYear = ExpYear() + 1
+	if update := service.UppfaeraGildistima(ctx, card); update.Err() != nil {
+		return update.Err()
+	}
+	if lastFour := service.FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri(ctx, card); lastFour.Err() != nil {
+		return lastFour.Err()
+	}
+	return nil
+}
+
+// RunConcurrently runs RunAllOperations n times at once while the settings of service are
+// replaced with a copy of themselves, every call must see a whole set. It returns the first error.
+func RunConcurrently(service *xmlcore.CompanyService, n int) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	rotated := make(chan struct{})
+	go func() {
+		defer close(rotated)
+		for ctx.Err() == nil {
+			service.SetSettings(service.Settings())
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- RunAllOperations(context.Background(), service)
+		}()
+	}
+	wg.Wait()
+	cancel()
+	<-rotated
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
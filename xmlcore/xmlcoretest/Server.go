@@ -0,0 +1,530 @@
+// Package xmlcoretest provides an in-process stand-in for the Fyrirtaekjagreidslur
+// SOAP service, so xmlcore can be tested without access to valitor.
+package xmlcoretest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensourcez/go-valitor/card"
+	"github.com/opensourcez/go-valitor/money"
+	"github.com/opensourcez/go-valitor/xmlcore"
+)
+
+const namespace = "http://api.valitor.is/Fyrirtaekjagreidslur/"
+
+// The credentials accepted by a new Server. Username and Password are made up,
+// they only work against the simulator.
+const (
+	Username               = "xmlcoretest-user"
+	Password               = "xmlcoretest-password"
+	ContractNumber         = "053128"
+	ContractIdentidyNumber = "5006830589"
+	PosID                  = "225"
+)
+
+// Villunumer values returned by the server. They are the simulator's own codes,
+// not the ones valitor uses, so tests should compare ValitorError.Code with them.
+const (
+	CodeInvalidCredentials    = 1
+	CodeInvalidContract       = 2
+	CodeInvalidPosID          = 3
+	CodeInvalidCardNumber     = 10
+	CodeExpiredCard           = 11
+	CodeInvalidCVC            = 12
+	CodeInvalidVirtualNumber  = 20
+	CodeInvalidAmount         = 40
+	CodeInvalidCurrency       = 41
+	CodeAuthorizationNotFound = 50
+	CodeAlreadyInvalidated    = 51
+)
+
+// TransactionKind ...
+type TransactionKind string
+
+const (
+	// Sale is created by FaHeimild.
+	Sale TransactionKind = "Sala"
+	// Authorization is created by FaAdeinsheimild and captured by NotaAdeinsheimild.
+	Authorization TransactionKind = "Heimild"
+	// Refund is created by FaEndurgreitt.
+	Refund TransactionKind = "Endurgreidsla"
+)
+
+// Transaction is a transaction created by the server.
+type Transaction struct {
+	ID            string
+	Kind          TransactionKind
+	VirtualNumber string
+	Amount        string
+	Currency      string
+	Captured      bool
+	Invalidated   bool
+}
+
+// VirtualCard is a virtual number issued by the server.
+type VirtualCard struct {
+	Number        string
+	VirtualNumber string
+	Expiry        card.Expiry
+}
+
+type injectedError struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+// Server is a fake Fyrirtaekjagreidslur SOAP service implementing all eight operations used by xmlcore.
+type Server struct {
+	*httptest.Server
+
+	// The credentials the server accepts.
+	Username               string
+	Password               string
+	ContractNumber         string
+	ContractIdentidyNumber string
+	PosID                  string
+
+	// Now is used for expiration checks, time.Now is used if it is nil.
+	Now func() time.Time
+
+	mu           sync.Mutex
+	cards        map[string]*VirtualCard
+	byNumber     map[string]string
+	transactions map[string]*Transaction
+	errors       map[string]injectedError
+	sequence     int
+}
+
+// NewServer starts a server with no virtual cards or transactions.
+// The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		Username:               Username,
+		Password:               Password,
+		ContractNumber:         ContractNumber,
+		ContractIdentidyNumber: ContractIdentidyNumber,
+		PosID:                  PosID,
+	}
+	s.reset()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetError makes every call to operation fail with the given Villunumer and Villuskilabod,
+// until ClearError or Reset is called. operation is the SOAP name, for example FaHeimild.
+func (s *Server) SetError(operation string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[operation] = injectedError{Code: code, Message: message}
+}
+
+// SetStatus makes every call to operation fail with the given HTTP status and an empty body,
+// until ClearError or Reset is called. Use http.StatusServiceUnavailable for an outage.
+func (s *Server) SetStatus(operation string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[operation] = injectedError{Status: status}
+}
+
+// ClearError removes an error set with SetError or SetStatus.
+func (s *Server) ClearError(operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.errors, operation)
+}
+
+// Reset forgets all virtual cards, transactions and injected errors.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+}
+
+func (s *Server) reset() {
+	s.cards = make(map[string]*VirtualCard)
+	s.byNumber = make(map[string]string)
+	s.transactions = make(map[string]*Transaction)
+	s.errors = make(map[string]injectedError)
+}
+
+// VirtualCard returns the virtual card issued for virtualNumber.
+func (s *Server) VirtualCard(virtualNumber string) (VirtualCard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.cards[virtualNumber]; ok {
+		return *c, true
+	}
+	return VirtualCard{}, false
+}
+
+// Transaction returns the transaction with the given Faerslunumer.
+func (s *Server) Transaction(id string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.transactions[id]; ok {
+		return *t, true
+	}
+	return Transaction{}, false
+}
+
+// =====================================================
+//
+// SOAP HANDLING
+//
+// =====================================================
+
+type requestEnvelope struct {
+	Body struct {
+		Operation operationRequest `xml:",any"`
+	} `xml:"Body"`
+}
+
+type operationRequest struct {
+	XMLName                xml.Name
+	Username               string `xml:"Notandanafn"`
+	Password               string `xml:"Lykilord"`
+	ContractNumber         string `xml:"Samningsnumer"`
+	ContractIdentidyNumber string `xml:"SamningsKennitala"`
+	PosID                  string `xml:"PosiID"`
+	CardNumber             string `xml:"Kortnumer"`
+	Expiration             string `xml:"Gildistimi"`
+	CVC                    string `xml:"Oryggisnumer"`
+	VirtualNumber          string `xml:"Syndarkortnumer"`
+	Amount                 string `xml:"Upphaed"`
+	Currency               string `xml:"Gjaldmidill"`
+	AuthorizationNumber    string `xml:"Faerslunumer"`
+	NewExpiration          string `xml:"NyrGildistimi"`
+}
+
+type responseEnvelope struct {
+	XMLName xml.Name `xml:"soap:Envelope"`
+	SOAP    string   `xml:"xmlns:soap,attr"`
+	Body    struct {
+		Response operationResponse
+	} `xml:"soap:Body"`
+}
+
+type operationResponse struct {
+	XMLName xml.Name
+	Result  operationResult
+}
+
+type operationResult struct {
+	XMLName       xml.Name
+	ErrorCode     int              `xml:"Villunumer"`
+	ErrorMessage  string           `xml:"Villuskilabod"`
+	ErrorLogID    string           `xml:"VilluLogID"`
+	VirtualNumber string           `xml:"Syndarkortnumer,omitempty"`
+	LastFour      string           `xml:"Kortnumer,omitempty"`
+	Receipt       *xmlcore.Receipt `xml:"Kvittun,omitempty"`
+}
+
+type handler func(r *operationRequest, result *operationResult)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	var envelope requestEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		writeFault(w, "soap:Client", "Server was unable to read request. "+err.Error())
+		return
+	}
+
+	request := &envelope.Body.Operation
+	operation := request.XMLName.Local
+	handlers := map[string]handler{
+		"FaSyndarkortnumer":  s.faSyndarkortnumer,
+		"FaHeimild":          s.faHeimild,
+		"FaAdeinsheimild":    s.faAdeinsheimild,
+		"NotaAdeinsheimild":  s.notaAdeinsheimild,
+		"FaEndurgreitt":      s.faEndurgreitt,
+		"FaOgildingu":        s.faOgildingu,
+		"UppfaeraGildistima": s.uppfaeraGildistima,
+		"FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri": s.faSidustuFjora,
+	}
+	handle, ok := handlers[operation]
+	if request.XMLName.Space != namespace || !ok {
+		writeFault(w, "soap:Client", "Server did not recognize the operation "+operation+".")
+		return
+	}
+
+	s.mu.Lock()
+	if injected, ok := s.errors[operation]; ok && injected.Status != 0 {
+		s.mu.Unlock()
+		w.WriteHeader(injected.Status)
+		return
+	}
+	result := operationResult{XMLName: xml.Name{Local: operation + "Result"}}
+	if injected, ok := s.errors[operation]; ok {
+		s.fail(&result, injected.Code, injected.Message)
+	} else if code := s.authenticate(request); code != 0 {
+		s.fail(&result, code, "Auðkenning mistókst")
+	} else {
+		handle(request, &result)
+	}
+	s.mu.Unlock()
+
+	var response responseEnvelope
+	response.SOAP = "http://schemas.xmlsoap.org/soap/envelope/"
+	response.Body.Response = operationResponse{
+		XMLName: xml.Name{Space: namespace, Local: operation + "Response"},
+		Result:  result,
+	}
+	out, err := xml.Marshal(&response)
+	if err != nil {
+		writeFault(w, "soap:Server", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+func writeFault(w http.ResponseWriter, code, message string) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `%s<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault><faultcode>%s</faultcode><faultstring>%s</faultstring><detail /></soap:Fault></soap:Body></soap:Envelope>`,
+		xml.Header, code, escape(message))
+}
+
+func escape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// fail and the operations below are called with s.mu held.
+func (s *Server) fail(result *operationResult, code int, message string) {
+	s.sequence++
+	result.ErrorCode = code
+	result.ErrorMessage = message
+	result.ErrorLogID = strconv.Itoa(s.sequence)
+}
+
+func (s *Server) authenticate(r *operationRequest) int {
+	if r.Username != s.Username || r.Password != s.Password {
+		return CodeInvalidCredentials
+	}
+	if r.ContractNumber != s.ContractNumber || r.ContractIdentidyNumber != s.ContractIdentidyNumber {
+		return CodeInvalidContract
+	}
+	switch r.XMLName.Local {
+	case "UppfaeraGildistima", "FaSidustuFjoraIKortnumeriUtFraSyndarkortnumeri":
+		// These operations do not send a PosiID
+	default:
+		if r.PosID != s.PosID {
+			return CodeInvalidPosID
+		}
+	}
+	return 0
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Server) virtualCard(r *operationRequest, result *operationResult) *VirtualCard {
+	c, ok := s.cards[r.VirtualNumber]
+	if !ok {
+		s.fail(result, CodeInvalidVirtualNumber, "Sýndarkortnúmer finnst ekki")
+	}
+	return c
+}
+
+// validAmount checks the amount and currency, the receipt echoes the amount as it was sent.
+func (s *Server) validAmount(r *operationRequest, result *operationResult) bool {
+	if _, err := money.ParseCurrency(r.Currency); err != nil {
+		s.fail(result, CodeInvalidCurrency, "Ógildur gjaldmiðill")
+		return false
+	}
+	if _, err := money.Parse(r.Amount, r.Currency); err != nil {
+		s.fail(result, CodeInvalidAmount, "Ógild upphæð")
+		return false
+	}
+	return true
+}
+
+func (s *Server) newTransaction(kind TransactionKind, c *VirtualCard, r *operationRequest) *xmlcore.Receipt {
+	s.sequence++
+	t := &Transaction{
+		ID:            fmt.Sprintf("%012d", s.sequence),
+		Kind:          kind,
+		VirtualNumber: c.VirtualNumber,
+		Amount:        r.Amount,
+		Currency:      r.Currency,
+	}
+	s.transactions[t.ID] = t
+	receipt := s.receipt(t, c)
+	receipt.Amount = r.Amount
+	return receipt
+}
+
+func (s *Server) receipt(t *Transaction, c *VirtualCard) *xmlcore.Receipt {
+	now := s.now()
+	return &xmlcore.Receipt{
+		CompanyName:     "xmlcoretest",
+		CardTypeName:    string(card.DetectBrand(c.Number)),
+		Date:            now.Format("02.01.2006"),
+		Time:            now.Format("15:04"),
+		MaskedPAN:       mask(c.Number),
+		TransactionID:   t.ID,
+		AuthorizationID: "A" + t.ID[len(t.ID)-5:],
+		Invalidated:     t.Invalidated,
+		Operation:       string(t.Kind),
+		TerminalID:      s.PosID,
+	}
+}
+
+func mask(number string) string {
+	number = card.Normalize(number)
+	if len(number) <= 10 {
+		return number
+	}
+	masked := []byte(number)
+	for i := 6; i < len(masked)-4; i++ {
+		masked[i] = '*'
+	}
+	return string(masked)
+}
+
+// =====================================================
+//
+// OPERATIONS
+//
+// =====================================================
+
+func (s *Server) faSyndarkortnumer(r *operationRequest, result *operationResult) {
+	if err := card.ValidateNumber(r.CardNumber); err != nil || r.CardNumber != card.Normalize(r.CardNumber) {
+		s.fail(result, CodeInvalidCardNumber, "Ógilt kortanúmer")
+		return
+	}
+	expiry, err := card.ParseMMYY(r.Expiration)
+	if err == nil {
+		err = expiry.Validate(s.now())
+	}
+	if err != nil {
+		s.fail(result, CodeExpiredCard, "Ógildur gildistími")
+		return
+	}
+	if r.CVC == "" {
+		s.fail(result, CodeInvalidCVC, "Öryggisnúmer vantar")
+		return
+	}
+
+	number := r.CardNumber
+	virtualNumber, ok := s.byNumber[number]
+	if !ok {
+		s.sequence++
+		virtualNumber = fmt.Sprintf("5999%012d", s.sequence)
+		s.byNumber[number] = virtualNumber
+		s.cards[virtualNumber] = &VirtualCard{Number: number, VirtualNumber: virtualNumber}
+	}
+	s.cards[virtualNumber].Expiry = expiry
+	result.VirtualNumber = virtualNumber
+}
+
+func (s *Server) faHeimild(r *operationRequest, result *operationResult) {
+	c := s.virtualCard(r, result)
+	if c == nil {
+		return
+	}
+	if s.validAmount(r, result) {
+		result.Receipt = s.newTransaction(Sale, c, r)
+	}
+}
+
+func (s *Server) faAdeinsheimild(r *operationRequest, result *operationResult) {
+	c := s.virtualCard(r, result)
+	if c == nil {
+		return
+	}
+	if r.CVC == "" {
+		s.fail(result, CodeInvalidCVC, "Öryggisnúmer vantar")
+		return
+	}
+	if s.validAmount(r, result) {
+		result.Receipt = s.newTransaction(Authorization, c, r)
+	}
+}
+
+func (s *Server) notaAdeinsheimild(r *operationRequest, result *operationResult) {
+	c := s.virtualCard(r, result)
+	if c == nil {
+		return
+	}
+	t, ok := s.transactions[r.AuthorizationNumber]
+	if !ok || t.Kind != Authorization || t.VirtualNumber != c.VirtualNumber {
+		s.fail(result, CodeAuthorizationNotFound, "Heimild finnst ekki")
+		return
+	}
+	if t.Captured || t.Invalidated {
+		s.fail(result, CodeAlreadyInvalidated, "Heimild hefur þegar verið notuð")
+		return
+	}
+	t.Captured = true
+}
+
+func (s *Server) faEndurgreitt(r *operationRequest, result *operationResult) {
+	c := s.virtualCard(r, result)
+	if c == nil {
+		return
+	}
+	if s.validAmount(r, result) {
+		result.Receipt = s.newTransaction(Refund, c, r)
+	}
+}
+
+func (s *Server) faOgildingu(r *operationRequest, result *operationResult) {
+	c := s.virtualCard(r, result)
+	if c == nil {
+		return
+	}
+	t, ok := s.transactions[r.AuthorizationNumber]
+	if !ok || t.VirtualNumber != c.VirtualNumber {
+		s.fail(result, CodeAuthorizationNotFound, "Færsla finnst ekki")
+		return
+	}
+	if t.Invalidated {
+		s.fail(result, CodeAlreadyInvalidated, "Búið er að ógilda færsluna")
+		return
+	}
+	if r.Currency != t.Currency {
+		s.fail(result, CodeInvalidCurrency, "Ógildur gjaldmiðill")
+		return
+	}
+	t.Invalidated = true
+	result.Receipt = s.receipt(t, c)
+}
+
+func (s *Server) uppfaeraGildistima(r *operationRequest, result *operationResult) {
+	c := s.virtualCard(r, result)
+	if c == nil {
+		return
+	}
+	expiry, err := card.ParseMMYY(r.NewExpiration)
+	if err == nil {
+		err = expiry.Validate(s.now())
+	}
+	if err != nil {
+		s.fail(result, CodeExpiredCard, "Ógildur gildistími")
+		return
+	}
+	c.Expiry = expiry
+}
+
+func (s *Server) faSidustuFjora(r *operationRequest, result *operationResult) {
+	if c := s.virtualCard(r, result); c != nil {
+		result.LastFour = card.LastFour(c.Number)
+	}
+}